@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// attemptLogPath is where every install attempt is recorded, one JSON object per line
+var attemptLogPath = "/var/log/kairos-installer-attempts.jsonl"
+
+// installAttempt records the outcome of an install
+type installAttempt struct {
+	Time     time.Time     `json:"time"`
+	Disk     string        `json:"disk"`
+	Duration time.Duration `json:"duration"`
+	Success  bool          `json:"success"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// String describes the attempt in a line
+func (a installAttempt) String() string {
+	outcome := "succeeded"
+	if !a.Success {
+		outcome = "failed"
+	}
+	s := fmt.Sprintf("Last install attempt on %s %s after %s (%s)", a.Disk, outcome, a.Duration.Round(time.Second), a.Time.Local().Format(time.DateTime))
+	if a.Error != "" {
+		s += ": " + a.Error
+	}
+	return s
+}
+
+// appendAttempt adds the attempt to the attempt log
+func appendAttempt(a installAttempt) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(attemptLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// lastAttempt returns the most recent attempt from the attempt log, if any
+func lastAttempt() (installAttempt, bool) {
+	var last installAttempt
+	f, err := os.Open(attemptLogPath)
+	if err != nil {
+		return last, false
+	}
+	defer f.Close()
+	found := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var a installAttempt
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			continue
+		}
+		last, found = a, true
+	}
+	return last, found
+}
@@ -1,18 +1,247 @@
 package main
 
 import (
+	_ "embed"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
 )
 
 // This sets the text for the installer, allowing to override it with custom branding
 
+// brandingEnv points to a branding directory that takes precedence over the system ones
+const brandingEnv = "KAIROS_BRANDING_DIR"
+
+// systemBrandingDirs are searched after the env override. /run comes first so earlier boot stages can
+// supply a branding at runtime over the one shipped in the image.
+var systemBrandingDirs = []string{"/run/kairos/branding", "/etc/kairos/branding"}
+
+// embeddedBranding is used when no branding directory exists, and fills whatever the found branding leaves out
+//
+//go:embed branding/default.yaml
+var embeddedBranding []byte
+
+// brandingDir is the first branding directory found in the search path, empty if there is none.
+// It holds either a single branding file or the older one-text-file-per-setting layout.
+var brandingDir = sync.OnceValue(func() string {
+	dirs := systemBrandingDirs
+	if dir := os.Getenv(brandingEnv); dir != "" {
+		dirs = append([]string{dir}, dirs...)
+	}
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+})
+
+// brandingFiles are tried in order, JSON being valid YAML the same parser reads both
+var brandingFiles = []string{"branding.yaml", "branding.yml", "branding.json"}
+
+// Branding is everything a distribution can customize in the installer
+type Branding struct {
+	Title         string   `json:"title,omitempty" yaml:"title,omitempty"`
+	Theme         string   `json:"theme,omitempty" yaml:"theme,omitempty"`
+	Welcome       string   `json:"welcome,omitempty" yaml:"welcome,omitempty"`
+	DisabledPages []string `json:"disabled_pages,omitempty" yaml:"disabled_pages,omitempty"`
+	// EULA is the path to a license the user accepts by starting the install, shown on the welcome page
+	EULA           string         `json:"eula,omitempty" yaml:"eula,omitempty"`
+	PasswordPolicy PasswordPolicy `json:"password_policy,omitempty" yaml:"password_policy,omitempty"`
+	// SkipDiskPrefixes hides the disks whose kernel name starts with any of them, e.g. loop or zram
+	SkipDiskPrefixes []string `json:"skip_disk_prefixes,omitempty" yaml:"skip_disk_prefixes,omitempty"`
+}
+
+// PasswordPolicy are the rules user passwords must follow, the zero value accepts anything
+type PasswordPolicy struct {
+	MinLength     int  `json:"min_length,omitempty" yaml:"min_length,omitempty"`
+	RequireDigit  bool `json:"require_digit,omitempty" yaml:"require_digit,omitempty"`
+	RequireUpper  bool `json:"require_upper,omitempty" yaml:"require_upper,omitempty"`
+	RequireSymbol bool `json:"require_symbol,omitempty" yaml:"require_symbol,omitempty"`
+}
+
+// Check returns why the password doesn't follow the policy, nil if it does
+func (p PasswordPolicy) Check(password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", p.MinLength)
+	}
+	if p.RequireDigit && !strings.ContainsFunc(password, unicode.IsDigit) {
+		return fmt.Errorf("password must contain a digit")
+	}
+	if p.RequireUpper && !strings.ContainsFunc(password, unicode.IsUpper) {
+		return fmt.Errorf("password must contain an uppercase letter")
+	}
+	if p.RequireSymbol && !strings.ContainsFunc(password, func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) }) {
+		return fmt.Errorf("password must contain a symbol")
+	}
+	return nil
+}
+
+// Describe is a short summary of the policy to show next to the password field, empty if there is none
+func (p PasswordPolicy) Describe() string {
+	var rules []string
+	if p.MinLength > 0 {
+		rules = append(rules, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+	if p.RequireDigit {
+		rules = append(rules, "a digit")
+	}
+	if p.RequireUpper {
+		rules = append(rules, "an uppercase letter")
+	}
+	if p.RequireSymbol {
+		rules = append(rules, "a symbol")
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+	return "Password needs " + strings.Join(rules, ", ")
+}
+
+// currentBranding is read once, the branding doesn't change while the installer runs
+var currentBranding = sync.OnceValue(loadBranding)
+
+// loadBranding reads the branding file and fills whatever it leaves empty from the legacy text files,
+// so existing brandings keep working and can be moved over one setting at a time. The embedded default
+// covers the rest.
+func loadBranding() Branding {
+	b := loadBrandingDir(brandingDir())
+	var def Branding
+	if err := yaml.Unmarshal(embeddedBranding, &def); err != nil {
+		panic(fmt.Sprintf("embedded branding is broken: %v", err))
+	}
+	if b.Title == "" {
+		b.Title = def.Title
+	}
+	if b.Theme == "" {
+		b.Theme = def.Theme
+	}
+	if b.Welcome == "" {
+		b.Welcome = def.Welcome
+	}
+	if b.DisabledPages == nil {
+		b.DisabledPages = def.DisabledPages
+	}
+	if b.EULA == "" {
+		b.EULA = def.EULA
+	}
+	if b.PasswordPolicy == (PasswordPolicy{}) {
+		b.PasswordPolicy = def.PasswordPolicy
+	}
+	if b.SkipDiskPrefixes == nil {
+		b.SkipDiskPrefixes = def.SkipDiskPrefixes
+	}
+	return b
+}
+
+// loadBrandingDir reads the branding in the given directory, the zero Branding if dir is empty
+func loadBrandingDir(dir string) Branding {
+	var b Branding
+	if dir == "" {
+		return b
+	}
+	for _, name := range brandingFiles {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if err := yaml.Unmarshal(data, &b); err != nil {
+			// Bad branding shouldn't stop the install, fall back to the defaults
+			fmt.Fprintf(os.Stderr, "Ignoring branding at %s: %v\n", path, err)
+			b = Branding{}
+		}
+		break
+	}
+	legacy := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+	}
+	if b.Title == "" {
+		b.Title = legacy("interactive_install_text")
+	}
+	if b.Theme == "" {
+		b.Theme = legacy("theme")
+	}
+	if b.Welcome == "" {
+		b.Welcome = legacy("welcome_text")
+	}
+	if b.DisabledPages == nil {
+		b.DisabledPages = strings.FieldsFunc(legacy("disabled_pages"), func(r rune) bool {
+			return r == ',' || r == '\n' || r == ' ' || r == '\t' || r == '\r'
+		})
+		if len(b.DisabledPages) == 0 {
+			b.DisabledPages = nil
+		}
+	}
+	return b
+}
+
 func DefaultTitle() string {
-	// Load it from a text file or something
-	branding, err := os.ReadFile(filepath.Join("/etc", "kairos", "branding", "interactive_install_text"))
-	if err == nil {
-		return string(branding)
-	} else {
-		return "Kairos Interactive Installer"
+	return currentBranding().Title
+}
+
+// DefaultTheme returns the name of the theme set by the branding, if any
+func DefaultTheme() string {
+	return currentBranding().Theme
+}
+
+// DefaultWelcome returns the text for the welcome page set by the branding, if any.
+// The welcome page is only shown when there is some text for it, or a license to accept.
+func DefaultWelcome() string {
+	return currentBranding().Welcome
+}
+
+// DisabledPages returns the IDs of the pages the branding doesn't want
+func DisabledPages() []string {
+	return currentBranding().DisabledPages
+}
+
+// EULAText returns the license set by the branding, empty if there is none or it can't be read
+func EULAText() string {
+	path := currentBranding().EULA
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Hook stages, each with its own directory of scripts in the branding
+const (
+	PreInstallHooks  = "pre_install"
+	PostInstallHooks = "post_install"
+)
+
+// InstallHooks returns the scripts the branding wants to run at the given hook stage, in lexical order.
+// They are the executable files in the <stage>.d directory of the branding, e.g. pre_install.d/10-check.sh
+func InstallHooks(stage string) []string {
+	if brandingDir() == "" {
+		return nil
+	}
+	dir := filepath.Join(brandingDir(), stage+".d")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var hooks []string
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		hooks = append(hooks, filepath.Join(dir, entry.Name()))
 	}
+	return hooks
 }
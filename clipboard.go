@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atotto/clipboard"
+)
+
+// copyConfigToClipboard copies the generated config to the system clipboard, returning a message with the outcome.
+// The secrets are masked as in the preview, anything can read the clipboard. There is usually no clipboard on a
+// bare console, that is reported rather than treated as an error.
+func copyConfigToClipboard() string {
+	if clipboard.Unsupported {
+		return "No clipboard available (needs xclip, xsel or wl-copy)"
+	}
+	out, err := NewInstallConfig(mainModel).MaskedYAML()
+	if err != nil {
+		mainModel.log.Printf("Error rendering config to copy: %v", err)
+		return fmt.Sprintf("Error rendering config: %v", err)
+	}
+	if err := clipboard.WriteAll(out); err != nil {
+		mainModel.log.Printf("Error copying config to the clipboard: %v", err)
+		return fmt.Sprintf("Could not copy to the clipboard: %v", err)
+	}
+	return "Configuration copied to the clipboard, with the secrets masked"
+}
+
+// errorReportPath is where the error details go when there is no clipboard to copy them to
+var errorReportPath = filepath.Join(os.TempDir(), "kairos-install-error.txt")
+
+// errorReportLogLines is how many of the latest installer output lines go into the error details
+const errorReportLogLines = 50
+
+// copyErrorDetails copies the install error and the tail of the installer output for a bug report. Without a
+// clipboard they are written to errorReportPath instead. It returns a message with the outcome.
+func copyErrorDetails(installErr error, logLines []string) string {
+	report := fmt.Sprintf("Error: %v\n", installErr)
+	if hint := errorGuidance(installErr); hint != "" {
+		report += hint + "\n"
+	}
+	if len(logLines) > errorReportLogLines {
+		logLines = logLines[len(logLines)-errorReportLogLines:]
+	}
+	report += "\nInstaller output:\n" + strings.Join(logLines, "\n") + "\n"
+
+	if !clipboard.Unsupported {
+		err := clipboard.WriteAll(report)
+		if err == nil {
+			return "Error details copied to the clipboard"
+		}
+		mainModel.log.Printf("Error copying error details to the clipboard: %v", err)
+	}
+	if err := os.WriteFile(errorReportPath, []byte(report), 0600); err != nil {
+		mainModel.log.Printf("Error writing error details to %s: %v", errorReportPath, err)
+		return fmt.Sprintf("Could not save the error details: %v", err)
+	}
+	return fmt.Sprintf("No clipboard available, error details saved to %s", errorReportPath)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxCloudConfigSize caps how much is read from a config server
+const maxCloudConfigSize = 1 << 20
+
+// newHTTPClient returns the client used for remote resources, honoring the HTTP(S)_PROXY and NO_PROXY
+// variables as installs often run behind a proxy
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+		},
+	}
+}
+
+// verifySHA256 checks the data against the expected hex encoded SHA256 digest, if one is given
+func verifySHA256(data []byte, expected string) error {
+	expected = strings.ToLower(strings.TrimSpace(expected))
+	if expected == "" {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return fmt.Errorf("SHA256 mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+// fetchCloudConfig downloads a cloud-config and checks it is a YAML document, and that it matches the
+// expected SHA256 digest if one is given
+func fetchCloudConfig(url, expectedSHA256 string) (map[string]any, error) {
+	resp, err := newHTTPClient().Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("fetching %s: server returned %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCloudConfigSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", url, err)
+	}
+	if len(data) > maxCloudConfigSize {
+		return nil, fmt.Errorf("config at %s is bigger than %d bytes", url, maxCloudConfigSize)
+	}
+	if err := verifySHA256(data, expectedSHA256); err != nil {
+		return nil, fmt.Errorf("config at %s: %w", url, err)
+	}
+	var cfg map[string]any
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("config at %s is not valid YAML: %w", url, err)
+	}
+	if len(cfg) == 0 {
+		return nil, fmt.Errorf("config at %s is empty or not a YAML mapping", url)
+	}
+	return cfg, nil
+}
+
+// mergeMaps returns base with over merged on top, over winning on conflicts. Nested maps are merged too.
+// Neither map is modified.
+func mergeMaps(base, over map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(over))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range over {
+		baseMap, baseIsMap := merged[k].(map[string]any)
+		overMap, overIsMap := v.(map[string]any)
+		if baseIsMap && overIsMap {
+			merged[k] = mergeMaps(baseMap, overMap)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// mergeBase puts the config on top of a base cloud-config, so the interactive answers win. The steps
+// of the base stages run before the ones from the answers.
+// install and stages are folded into their own maps, inlining them next to those would collide.
+func (c *InstallConfig) mergeBase(base map[string]any) {
+	extra := map[string]any{}
+	for key, value := range base {
+		switch key {
+		case "install":
+			if install, ok := value.(map[string]any); ok {
+				c.Install = mergeMaps(install, c.Install)
+			} else {
+				mainModel.log.Printf("Ignoring install set to %v, it has to be a map", value)
+			}
+			continue
+		case "stages":
+			if stages, ok := value.(map[string]any); ok {
+				c.Stages = mergeStages(stages, c.Stages)
+			} else {
+				mainModel.log.Printf("Ignoring stages set to %v, it has to be a map", value)
+			}
+			continue
+		}
+		extra[key] = value
+	}
+	c.ExtraFields = mergeMaps(extra, c.ExtraFields)
+}
+
+// mergeStages appends the steps of each stage in over to the ones in base
+func mergeStages(base, over map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(over))
+	for stage, steps := range base {
+		merged[stage] = steps
+	}
+	for stage, steps := range over {
+		merged[stage] = append(stageSteps(merged[stage]), stageSteps(steps)...)
+	}
+	return merged
+}
+
+// stageSteps returns the steps of a stage as a generic list
+func stageSteps(steps any) []any {
+	switch s := steps.(type) {
+	case []any:
+		return s
+	case []map[string]any:
+		list := make([]any, 0, len(s))
+		for _, step := range s {
+			list = append(list, step)
+		}
+		return list
+	case nil:
+		return nil
+	}
+	return []any{steps}
+}
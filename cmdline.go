@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// cmdlineDeviceKey is the kernel command line parameter hinting which disk to install to
+const cmdlineDeviceKey = "kairos.install.device"
+
+// cmdlineValue returns the value of the given key=value parameter from the kernel command line
+func cmdlineValue(key string) (string, bool) {
+	data, err := os.ReadFile("/proc/cmdline")
+	if err != nil {
+		return "", false
+	}
+	for _, field := range strings.Fields(string(data)) {
+		k, v, found := strings.Cut(field, "=")
+		if found && k == key {
+			return strings.Trim(v, `"`), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// completionMarkerPath is where the install outcome is written for external monitoring
+var completionMarkerPath = "/run/kairos-install-complete"
+
+// writeCompletionMarker records how the install ended, so orchestrators can tell without scraping the
+// screen. It is best effort, failing to write it doesn't change the outcome of the install.
+func writeCompletionMarker(installErr error) {
+	if completionMarkerPath == "" {
+		return
+	}
+	status, exitCode := "success", 0
+	if installErr != nil {
+		status, exitCode = "failed", 1
+		var exitErr *exec.ExitError
+		if errors.As(installErr, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
+	}
+	s := fmt.Sprintf("status=%s\nexit_code=%d\ntimestamp=%s\n", status, exitCode, time.Now().UTC().Format(time.RFC3339))
+	if installErr != nil {
+		s += fmt.Sprintf("error=%q\n", installErr.Error())
+	}
+	if err := os.WriteFile(completionMarkerPath, []byte(s), 0644); err != nil {
+		mainModel.log.Printf("Error writing completion marker %s: %v", completionMarkerPath, err)
+		return
+	}
+	mainModel.log.Printf("Wrote completion marker %s: %s", completionMarkerPath, status)
+}
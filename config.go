@@ -1,13 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"os"
 
 	"gopkg.in/yaml.v3"
 )
 
 // InstallConfig holds fixed and dynamic install fields
-// Fixed fields: Username, Password, SSHKeys
+// Fixed fields: Users, with their SSH keys
 // Dynamic fields: stored in ExtraFields
 
 type InstallConfig struct {
@@ -25,33 +26,96 @@ func NewInstallConfig(m model) *InstallConfig {
 	}
 
 	installConfig.Install["device"] = m.disk
+	if m.kernelArgs != "" {
+		installConfig.Install["grub_options"] = map[string]any{
+			"extra_cmdline": m.kernelArgs,
+		}
+	}
+
+	if m.imageMirror != "" {
+		installConfig.Install["source"] = "oci:" + m.imageMirror
+	}
+
+	persistent := map[string]any{}
+	if m.filesystem != "" {
+		persistent["fs"] = m.filesystem
+	}
+	if m.persistentSize > 0 {
+		// In MiB, the agent uses the rest of the disk when not set
+		persistent["size"] = m.persistentSize
+	}
+	if len(persistent) > 0 {
+		installConfig.Install["partitions"] = map[string]any{"persistent": persistent}
+	}
 
-	if m.username != "" && m.password != "" {
+	if len(m.users) > 0 {
 		stage := "initramfs"
 
 		// If we have ssh keys we need to delay the user creation to the network stage so we can get those keys
-		if m.sshKeys != nil && len(m.sshKeys) > 0 {
-			stage = "network"
+		users := map[string]any{}
+		var sudoers []map[string]any
+		for i, u := range m.users {
+			keys := u.SSHKeys
+			groups := u.Groups
+			if i == 0 && len(groups) == 0 {
+				groups = defaultPrimaryGroups
+			}
+			if len(keys) > 0 {
+				stage = "network"
+			}
+			user := map[string]any{
+				"passwd": u.Password,
+			}
+			if len(groups) > 0 {
+				user["groups"] = groups
+			}
+			if len(keys) > 0 {
+				user["ssh_authorized_keys"] = keys
+			}
+			if u.Shell != "" {
+				user["shell"] = u.Shell
+			}
+			users[u.Name] = user
+			if u.SudoNoPasswd {
+				sudoers = append(sudoers, map[string]any{
+					"path":        "/etc/sudoers.d/" + u.Name,
+					"content":     u.Name + " ALL=(ALL) NOPASSWD: ALL\n",
+					"permissions": 0440,
+				})
+			}
 		}
-		installConfig.Stages[stage] = []map[string]any{
-			{
-				"name": "Set user and password",
-				"users": map[string]any{
-					"kairos": map[string]any{
-						"passwd":              m.password,
-						"groups":              []string{"admin"},
-						"ssh_authorized_keys": m.sshKeys,
-					},
-				},
-			},
+		step := map[string]any{
+			"name":  "Set users and passwords",
+			"users": users,
 		}
+		if len(sudoers) > 0 {
+			step["files"] = sudoers
+		}
+		installConfig.Stages[stage] = []map[string]any{step}
 	} else {
 		// No users set, we need to skip the user validation
 		installConfig.Install["nousers"] = true
 	}
 
-	// Always set the extra fields
-	installConfig.ExtraFields = m.extraFields
+	// Custom steps run after the ones set up here in the same stage
+	for _, step := range m.customSteps {
+		steps, _ := installConfig.Stages[step.Stage].([]map[string]any)
+		installConfig.Stages[step.Stage] = append(steps, step.config())
+	}
+
+	// Always set the extra fields. Plugins answering under install or stages add to what is set up above,
+	// which wins on conflicts.
+	installConfig.mergeBase(m.extraFields)
+
+	if m.baseConfig != nil {
+		installConfig.mergeBase(m.baseConfig)
+	}
+
+	// The hostname page offers a generated one, it is used even if the page was never opened. Not when
+	// rescuing, the installation keeps its hostname unless one is given.
+	if _, ok := installConfig.ExtraFields[hostnameSection]; !ok && m.defaultHostname != "" && !m.rescue {
+		installConfig.ExtraFields[hostnameSection] = m.defaultHostname
+	}
 
 	return &installConfig
 }
@@ -68,3 +132,58 @@ func (c *InstallConfig) WriteYAML(path string) error {
 	defer enc.Close()
 	return enc.Encode(c)
 }
+
+// YAML returns the config rendered as a YAML document
+func (c *InstallConfig) YAML() (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// MaskedYAML returns the config rendered as a YAML document with the secrets masked, user passwords and
+// anything under a section named like a secret, to be shown on screen
+func (c *InstallConfig) MaskedYAML() (string, error) {
+	var doc yaml.Node
+	if err := doc.Encode(c); err != nil {
+		return "", err
+	}
+	maskSecretNodes(&doc, false)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// maskSecretNodes masks the scalar values in n, all of them if secret is set, else those under a key
+// isSecretSection matches
+func maskSecretNodes(n *yaml.Node, secret bool) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			maskSecretNodes(n.Content[i+1], secret || isSecretSection(n.Content[i].Value))
+		}
+	case yaml.ScalarNode:
+		if secret && n.Value != "" {
+			n.Value = maskSecret(n.Value)
+			n.Tag = "!!str"
+			n.Style = 0
+		}
+	default:
+		for _, child := range n.Content {
+			maskSecretNodes(child, secret)
+		}
+	}
+}
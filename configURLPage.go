@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// cloudConfigFetchedMsg is sent once the base config was downloaded
+type cloudConfigFetchedMsg struct {
+	url    string
+	config map[string]any
+	err    error
+}
+
+// fetchCloudConfigCmd downloads the base config in the background
+func fetchCloudConfigCmd(url, expectedSHA256 string) tea.Cmd {
+	return func() tea.Msg {
+		cfg, err := fetchCloudConfig(url, expectedSHA256)
+		return cloudConfigFetchedMsg{url: url, config: cfg, err: err}
+	}
+}
+
+// Config URL Page, loads a cloud-config from a config server to use as the base of the generated one
+type configURLPage struct {
+	input    textinput.Model
+	sumInput textinput.Model // Expected SHA256 of the config, optional
+	focused  int             // 0 = URL, 1 = SHA256
+	fetching bool
+	spinner  spinner.Model
+	err      error
+}
+
+func newConfigURLPage() *configURLPage {
+	input := textinput.New()
+	input.Placeholder = "https://config.example.com/kairos.yaml"
+	input.Width = 60
+	input.Focus()
+	sumInput := textinput.New()
+	sumInput.Placeholder = "optional, hex encoded"
+	sumInput.Width = 64
+	sumInput.CharLimit = 64
+	s := spinner.New(spinner.WithSpinner(glyphs.Spinner))
+	s.Style = lipgloss.NewStyle().Foreground(kairosAccent)
+	return &configURLPage{input: input, sumInput: sumInput, spinner: s}
+}
+
+func (p *configURLPage) Init() tea.Cmd {
+	p.input.SetValue(mainModel.baseConfigURL)
+	p.sumInput.SetValue(mainModel.baseConfigSHA256)
+	p.err = nil
+	return textinput.Blink
+}
+
+// inputs returns the text inputs in focus order
+func (p *configURLPage) inputs() []*textinput.Model {
+	return []*textinput.Model{&p.input, &p.sumInput}
+}
+
+func (p *configURLPage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		if !p.fetching {
+			return p, nil
+		}
+		p.spinner, cmd = p.spinner.Update(msg)
+		return p, cmd
+	case cloudConfigFetchedMsg:
+		p.fetching = false
+		if p.err = msg.err; msg.err != nil {
+			mainModel.log.Printf("Error loading base config: %v", msg.err)
+			return p, nil
+		}
+		mainModel.log.Printf("Loaded base config from %s", msg.url)
+		mainModel.baseConfigURL = msg.url
+		mainModel.baseConfigSHA256 = strings.TrimSpace(p.sumInput.Value())
+		mainModel.baseConfig = msg.config
+		return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+	case tea.KeyMsg:
+		if p.fetching {
+			return p, nil
+		}
+		switch msg.String() {
+		case "tab":
+			p.focused, cmd = cycleFocus(p.inputs(), p.focused, 1)
+			return p, cmd
+		case "shift+tab":
+			p.focused, cmd = cycleFocus(p.inputs(), p.focused, -1)
+			return p, cmd
+		case "enter":
+			url := strings.TrimSpace(p.input.Value())
+			if url == "" {
+				// No URL, no base config
+				mainModel.baseConfigURL = ""
+				mainModel.baseConfigSHA256 = ""
+				mainModel.baseConfig = nil
+				return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+			}
+			if p.err = validateURL(url); p.err != nil {
+				return p, nil
+			}
+			p.err = nil
+			p.fetching = true
+			return p, tea.Batch(p.spinner.Tick, fetchCloudConfigCmd(url, p.sumInput.Value()))
+		case "esc":
+			// Go back to customization page
+			return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+		}
+	}
+
+	input := p.inputs()[p.focused]
+	*input, cmd = input.Update(msg)
+	return p, cmd
+}
+
+func (p *configURLPage) View() string {
+	s := "Base Configuration\n\n"
+	s += fieldLabel("URL of a cloud-config to start from, the answers given here are merged on top of it:", p.focused == 0) + "\n"
+	s += p.input.View() + "\n\n"
+	s += fieldLabel("Expected SHA256 of the config, checked before using it:", p.focused == 1) + "\n"
+	s += p.sumInput.View() + "\n\n"
+	s += "Leave the URL empty to not use a base config.\n"
+
+	switch {
+	case p.fetching:
+		s += "\n" + p.spinner.View() + " Fetching config..."
+	case p.err != nil:
+		s += "\n" + lipgloss.NewStyle().Foreground(kairosHighlight2).Render(fmt.Sprintf("%s %v", glyphs.Warning, p.err))
+	case mainModel.baseConfigURL != "":
+		s += fmt.Sprintf("\n%s Using base config from %s", glyphs.Check, mainModel.baseConfigURL)
+	}
+
+	return s
+}
+
+func (p *configURLPage) Title() string {
+	return "Base Configuration"
+}
+
+func (p *configURLPage) Help() string {
+	return "tab/shift+tab: switch fields • enter: fetch and use • esc: cancel"
+}
+
+func (p *configURLPage) ID() string { return "config_url" }
+
+func (p *configURLPage) BackTarget() string { return "customization" }
+
+func (p *configURLPage) TakingTextInput() bool { return !p.fetching }
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"io"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestNewInstallConfigInstallAndStagesKeys(t *testing.T) {
+	oldModel := mainModel
+	t.Cleanup(func() { mainModel = oldModel })
+	mainModel = model{log: log.New(io.Discard, "", 0)}
+
+	customStep := map[string]any{"name": "custom", "commands": []any{"true"}}
+	pluginStep := map[string]any{"name": "plugin", "commands": []any{"echo plugin"}}
+	tests := []struct {
+		name        string
+		kernelArgs  string
+		extraFields map[string]any
+		baseConfig  map[string]any
+		wantInstall map[string]any // Keys expected in install, with their values
+		wantBoot    []any          // Expected steps of the boot stage
+		wantExtra   map[string]any // Expected top level keys besides install and stages
+	}{
+		{
+			name:        "no install or stages",
+			extraFields: map[string]any{"hostname": "node"},
+			wantInstall: map[string]any{"device": "/dev/sda"},
+			wantBoot:    []any{customStep},
+			wantExtra:   map[string]any{"hostname": "node"},
+		},
+		{
+			name:        "install keys are added",
+			extraFields: map[string]any{"install": map[string]any{"auto": true, "reboot": true}},
+			wantInstall: map[string]any{"device": "/dev/sda", "auto": true, "reboot": true},
+			wantBoot:    []any{customStep},
+		},
+		{
+			name:        "answers win over install keys",
+			kernelArgs:  "console=ttyS0",
+			extraFields: map[string]any{"install": map[string]any{"device": "/dev/sdb", "grub_options": map[string]any{"timeout": 5}}},
+			wantInstall: map[string]any{"device": "/dev/sda", "grub_options": map[string]any{"timeout": 5, "extra_cmdline": "console=ttyS0"}},
+			wantBoot:    []any{customStep},
+		},
+		{
+			name:        "stage steps run before the custom ones",
+			extraFields: map[string]any{"stages": map[string]any{"boot": []any{pluginStep}}},
+			wantInstall: map[string]any{"device": "/dev/sda"},
+			wantBoot:    []any{pluginStep, customStep},
+		},
+		{
+			name:        "install and stages that are not maps are dropped",
+			extraFields: map[string]any{"install": "yes", "stages": []any{"boot"}, "hostname": "node"},
+			wantInstall: map[string]any{"device": "/dev/sda"},
+			wantBoot:    []any{customStep},
+			wantExtra:   map[string]any{"hostname": "node"},
+		},
+		{
+			name:        "base config under the answers",
+			extraFields: map[string]any{"install": map[string]any{"auto": true}},
+			baseConfig: map[string]any{
+				"install":  map[string]any{"auto": false, "poweroff": true},
+				"stages":   map[string]any{"boot": []any{pluginStep}},
+				"hostname": "base",
+			},
+			wantInstall: map[string]any{"device": "/dev/sda", "auto": true, "poweroff": true},
+			wantBoot:    []any{pluginStep, customStep},
+			wantExtra:   map[string]any{"hostname": "base"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := model{
+				disk:        "/dev/sda",
+				kernelArgs:  tt.kernelArgs,
+				customSteps: []stageStep{{Stage: "boot", Name: "custom", Commands: []string{"true"}}},
+				extraFields: tt.extraFields,
+				baseConfig:  tt.baseConfig,
+			}
+			doc := configDoc(t, NewInstallConfig(m))
+
+			install, _ := doc["install"].(map[string]any)
+			for key, want := range tt.wantInstall {
+				if got := install[key]; !reflect.DeepEqual(got, want) {
+					t.Errorf("install.%s = %#v, want %#v", key, got, want)
+				}
+			}
+			stages, _ := doc["stages"].(map[string]any)
+			if got := stages["boot"]; !reflect.DeepEqual(got, tt.wantBoot) {
+				t.Errorf("stages.boot = %#v, want %#v", got, tt.wantBoot)
+			}
+			delete(doc, "install")
+			delete(doc, "stages")
+			if len(doc) == 0 {
+				doc = nil
+			}
+			if !reflect.DeepEqual(doc, tt.wantExtra) {
+				t.Errorf("other keys = %#v, want %#v", doc, tt.wantExtra)
+			}
+		})
+	}
+}
+
+// configDoc returns the config as the agent reads it
+func configDoc(t *testing.T, cfg *InstallConfig) map[string]any {
+	t.Helper()
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("marshaling config: %v", err)
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("reading back config: %v\n%s", err, data)
+	}
+	return doc
+}
+
+func TestMaskedYAML(t *testing.T) {
+	oldModel := mainModel
+	t.Cleanup(func() { mainModel = oldModel })
+	mainModel = model{log: log.New(io.Discard, "", 0)}
+
+	m := model{
+		disk:  "/dev/sda",
+		users: []userAccount{{Name: "kairos", Password: "hunter2"}, {Name: "admin", Password: "s3cret-pw"}},
+		extraFields: map[string]any{
+			"hostname":  "node",
+			"k3s":       map[string]any{"enabled": true, "token": "k3s-token"},
+			"api_token": 1234,
+			"secrets":   []any{"first-secret", map[string]any{"value": "second-secret"}},
+		},
+	}
+	out, err := NewInstallConfig(m).MaskedYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"hunter2", "s3cret-pw", "k3s-token", "1234", "first-secret", "second-secret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("masked config shows %q:\n%s", secret, out)
+		}
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("reading back masked config: %v\n%s", err, out)
+	}
+	if doc["hostname"] != "node" || doc["k3s"].(map[string]any)["enabled"] != true {
+		t.Errorf("masked config lost the other values:\n%s", out)
+	}
+	if doc["api_token"] != maskSecret("1234") {
+		t.Errorf("api_token = %#v, want it masked", doc["api_token"])
+	}
+}
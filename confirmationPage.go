@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Confirmation Page, asks the user to confirm wiping the selected disk
+type confirmationPage struct {
+	cursor  int
+	options []string
+}
+
+func newConfirmationPage() *confirmationPage {
+	return &confirmationPage{
+		options: []string{"Yes", "No"},
+		cursor:  1, // Default to "No"
+	}
+}
+
+func (p *confirmationPage) Init() tea.Cmd {
+	return nil
+}
+
+func (p *confirmationPage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			p.cursor = 0
+		case "down", "j":
+			p.cursor = 1
+		case "y", "Y":
+			p.cursor = 0
+			return p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		case "n", "N":
+			p.cursor = 1
+			return p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		case "enter":
+			if p.cursor == 0 {
+				if labels := mainModel.selectedDisk.kairosLabels(); len(labels) > 0 {
+					mainModel.log.Printf("Existing Kairos installation on %s will be wiped (%v)", mainModel.disk, labels)
+				}
+				mainModel.log.Printf("Disk wipe confirmed for %s", mainModel.disk)
+				mainModel.wipeConfirmed = true
+				return p, func() tea.Msg { return GoToPageMsg{PageID: "install_options"} }
+			}
+			// Go back to pick another disk
+			mainModel.wipeConfirmed = false
+			return p, func() tea.Msg { return GoToPageMsg{PageID: "disk_selection"} }
+		}
+	}
+	return p, nil
+}
+
+func (p *confirmationPage) View() string {
+	s := fmt.Sprintf("%s WARNING: All data on %s will be DESTROYED!\n\n", glyphs.Warning, mainModel.disk)
+	if labels := mainModel.selectedDisk.kairosLabels(); len(labels) > 0 {
+		s += lipgloss.NewStyle().Foreground(kairosHighlight2).Bold(true).Render(
+			fmt.Sprintf("%s An existing Kairos installation was found on this disk (%s).", glyphs.Warning, strings.Join(labels, ", ")),
+		) + "\n"
+		s += "Continuing will wipe it. To keep its data, upgrade the existing installation instead.\n\n"
+	}
+	s += wipePreview(mainModel.selectedDisk) + "\n"
+	s += "Are you sure you want to continue? (y/n)\n\n"
+
+	for i, option := range p.options {
+		s += listItem(i, p.cursor, len(p.options), option) + "\n"
+	}
+
+	return s
+}
+
+// wipePreview lists what is on the disk now, so it is clear what is about to be lost
+func wipePreview(disk diskStruct) string {
+	if len(disk.partitions) == 0 {
+		return "The disk has no partitions, there is no existing data to lose.\n"
+	}
+	s := "These partitions and everything in them will be erased:\n"
+	for _, part := range disk.partitions {
+		line := "  - " + partitionSummary(part)
+		if part.MountPoint != "" {
+			// In use right now, most likely not what the user meant to wipe
+			line = lipgloss.NewStyle().Foreground(kairosHighlight2).Render(line)
+		}
+		s += line + "\n"
+	}
+	return s
+}
+
+func (p *confirmationPage) Title() string {
+	return "Confirm Disk Wipe"
+}
+
+func (p *confirmationPage) Help() string {
+	return genericNavigationHelp + " • y/n: yes/no"
+}
+
+func (p *confirmationPage) ID() string { return "confirmation" }
+
+func (p *confirmationPage) BackTarget() string { return "disk_selection" }
+
+// AutoAdvance confirms the wipe, it only happens when the page was explicitly made unattended
+func (p *confirmationPage) AutoAdvance() tea.Cmd {
+	p.cursor = 0
+	_, cmd := p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return cmd
+}
@@ -3,44 +3,48 @@ package main
 import (
 	"os"
 	"strings"
-
-	"github.com/charmbracelet/lipgloss"
 )
 
 var (
-	// Default to true color palette
-	kairosBg         = lipgloss.Color("#03153a") // Deep blue background
-	kairosHighlight  = lipgloss.Color("#e56a44") // Orange highlight
-	kairosHighlight2 = lipgloss.Color("#d54b11") // Red-orange highlight
-	kairosAccent     = lipgloss.Color("#ee5007") // Accent orange
-	kairosBorder     = lipgloss.Color("#e56a44") // Use highlight for border
-	kairosText       = lipgloss.Color("#ffffff") // White text for contrast
-	checkMark        = "✓"
+	// Colors in use, see theme.go
+	kairosBg         = defaultTheme.Bg
+	kairosHighlight  = defaultTheme.Highlight
+	kairosHighlight2 = defaultTheme.Highlight2
+	kairosAccent     = defaultTheme.Accent
+	kairosBorder     = defaultTheme.Border
+	kairosText       = defaultTheme.Text
+	kairosSuccess    = defaultTheme.Success
+	kairosFailure    = defaultTheme.Failure
 )
 
 func init() {
 	// Fallback colors for terminal environments that do not support true color
 	term := os.Getenv("TERM")
 	if strings.Contains(term, "linux") || strings.Contains(term, "-16color") || term == "dumb" {
-		kairosBg = lipgloss.Color("0")         // Black
-		kairosText = lipgloss.Color("7")       // White
-		kairosHighlight = lipgloss.Color("9")  // Bright Red (for title)
-		kairosHighlight2 = lipgloss.Color("1") // Red (for minor alerts or secondary info)
-		kairosAccent = lipgloss.Color("5")     // Magenta (or "13" if brighter is OK)
-		kairosBorder = lipgloss.Color("9")     // Bright Red (matches highlight)
-		checkMark = "*"                        // Use a check mark that works in most terminals
+		baseTheme = consoleTheme
+		applyTheme(consoleTheme)
+		glyphs = consoleGlyphs // No emoji support on the console
+	}
+
+	// Serial consoles (IPMI, vt220...) can't draw box characters, stick to plain ASCII there
+	if isLimitedTerm(term) {
+		glyphs = asciiGlyphs
 	}
 }
 
+// isLimitedTerm reports whether the given TERM is only able to render ASCII
+func isLimitedTerm(term string) bool {
+	return term == "" || term == "dumb" || term == "ansi" || strings.HasPrefix(term, "vt")
+}
+
 const (
 	genericNavigationHelp = "↑/k: up • ↓/j: down • enter: select"
-	StepPrefix            = "STEP:"
-	ErrorPrefix           = "ERROR:"
 )
 
 // Installation steps for show
 const (
 	InstallDefaultStep       = "Preparing installation"
+	InstallPreHooksStep      = "Running pre-install hooks"
 	InstallPartitionStep     = "Partitioning disk"
 	InstallBeforeInstallStep = "Running before-install"
 	InstallActiveStep        = "Installing Active"
@@ -48,9 +52,26 @@ const (
 	InstallRecoveryStep      = "Creating Recovery"
 	InstallPassiveStep       = "Creating Passive"
 	InstallAfterInstallStep  = "Running after-install"
+	InstallPostHooksStep     = "Running post-install hooks"
 	InstallCompleteStep      = "Installation complete!"
 )
 
+// defaultStepWeights is roughly how long each step takes on a typical install, relative to the others.
+// The finishing step has no weight, reaching it means the install is done. Steps not listed weigh 1.
+var defaultStepWeights = map[string]int{
+	InstallDefaultStep:       1,
+	InstallPreHooksStep:      1,
+	InstallPartitionStep:     2,
+	InstallBeforeInstallStep: 1,
+	InstallActiveStep:        10,
+	InstallBootloaderStep:    2,
+	InstallRecoveryStep:      5,
+	InstallPassiveStep:       5,
+	InstallAfterInstallStep:  1,
+	InstallPostHooksStep:     1,
+	InstallCompleteStep:      0,
+}
+
 // Installation steps to identify installer to UI
 const (
 	AgentPartitionLog     = "Partitioning device"
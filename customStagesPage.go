@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// stageStep is a custom provisioning step run by the installed system at the given stage
+type stageStep struct {
+	Stage    string      `json:"stage" yaml:"stage"`
+	Name     string      `json:"name" yaml:"name"`
+	Commands []string    `json:"commands,omitempty" yaml:"commands,omitempty"`
+	Files    []stageFile `json:"files,omitempty" yaml:"files,omitempty"`
+}
+
+// stageFile is a file written by a custom step
+type stageFile struct {
+	Path    string `json:"path" yaml:"path"`
+	Content string `json:"content" yaml:"content"`
+}
+
+// kairosStages are the stages the installed system runs, each can also be hooked .before or .after
+var kairosStages = []string{
+	"rootfs", "initramfs", "boot", "fs", "network", "reconcile",
+	"before-install", "after-install", "after-install-chroot",
+	"before-upgrade", "after-upgrade", "after-upgrade-chroot",
+	"before-reset", "after-reset", "after-reset-chroot",
+}
+
+// validateStageName checks the stage is one Kairos runs, optionally with a .before or .after suffix
+func validateStageName(stage string) error {
+	base := strings.TrimSuffix(strings.TrimSuffix(stage, ".before"), ".after")
+	if slices.Contains(kairosStages, base) {
+		return nil
+	}
+	return fmt.Errorf("unknown stage %q, use one of %s, optionally followed by .before or .after", stage, strings.Join(kairosStages, ", "))
+}
+
+// config returns the step as written in the stages of the cloud config
+func (s stageStep) config() map[string]any {
+	step := map[string]any{"name": s.Name}
+	if len(s.Commands) > 0 {
+		step["commands"] = s.Commands
+	}
+	var files []map[string]any
+	for _, f := range s.Files {
+		files = append(files, map[string]any{"path": f.Path, "content": f.Content, "permissions": 0644})
+	}
+	if len(files) > 0 {
+		step["files"] = files
+	}
+	return step
+}
+
+// Custom Stages Page, expert option to add provisioning steps to any stage of the installed system
+type customStagesPage struct {
+	cursor  int
+	adding  bool // Typing in a new step
+	focused int  // 0 = stage, 1 = name, 2 = commands, 3 = file path, 4 = file content
+	err     string
+
+	stageInput    textinput.Model
+	nameInput     textinput.Model
+	commandsInput textinput.Model
+	pathInput     textinput.Model
+	contentInput  textinput.Model
+}
+
+func newCustomStagesPage() *customStagesPage {
+	stageInput := textinput.New()
+	stageInput.Placeholder = "boot"
+	stageInput.Width = 30
+	stageInput.SetSuggestions(kairosStages)
+	stageInput.ShowSuggestions = true
+
+	nameInput := textinput.New()
+	nameInput.Placeholder = "Set up monitoring"
+	nameInput.Width = 40
+
+	commandsInput := textinput.New()
+	commandsInput.Placeholder = "systemctl enable node-exporter; touch /etc/monitored"
+	commandsInput.Width = 60
+
+	pathInput := textinput.New()
+	pathInput.Placeholder = "/etc/motd"
+	pathInput.Width = 40
+
+	contentInput := textinput.New()
+	contentInput.Placeholder = `Welcome!\n`
+	contentInput.Width = 60
+
+	return &customStagesPage{
+		stageInput:    stageInput,
+		nameInput:     nameInput,
+		commandsInput: commandsInput,
+		pathInput:     pathInput,
+		contentInput:  contentInput,
+	}
+}
+
+func (p *customStagesPage) Init() tea.Cmd {
+	p.stopAdding()
+	return nil
+}
+
+// inputs returns the text inputs in focus order
+func (p *customStagesPage) inputs() []*textinput.Model {
+	return []*textinput.Model{&p.stageInput, &p.nameInput, &p.commandsInput, &p.pathInput, &p.contentInput}
+}
+
+// stopAdding empties the inputs and goes back to the list of steps
+func (p *customStagesPage) stopAdding() {
+	p.adding = false
+	p.err = ""
+	for _, input := range p.inputs() {
+		input.SetValue("")
+		input.Blur()
+	}
+}
+
+func (p *customStagesPage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	var cmd tea.Cmd
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+	if !p.adding {
+		switch keyMsg.String() {
+		case "up", "k":
+			if p.cursor > 0 {
+				p.cursor--
+			}
+		case "down", "j":
+			if p.cursor < len(mainModel.customSteps) { // +1 for "Add step"
+				p.cursor++
+			}
+		case "d":
+			if p.cursor < len(mainModel.customSteps) {
+				mainModel.log.Printf("Removing custom step %s", mainModel.customSteps[p.cursor].Name)
+				mainModel.customSteps = slices.Delete(mainModel.customSteps, p.cursor, p.cursor+1)
+			}
+		case "a", "enter":
+			if p.cursor == len(mainModel.customSteps) {
+				p.adding = true
+				p.focused = 0
+				return p, p.stageInput.Focus()
+			}
+		}
+		return p, nil
+	}
+
+	switch keyMsg.String() {
+	case "tab":
+		if s := p.stageInput.CurrentSuggestion(); p.stageInput.Focused() && s != "" && s != p.stageInput.Value() {
+			// Complete the stage first
+			break
+		}
+		p.focused, cmd = cycleFocus(p.inputs(), p.focused, 1)
+		return p, cmd
+	case "shift+tab":
+		p.focused, cmd = cycleFocus(p.inputs(), p.focused, -1)
+		return p, cmd
+	case "enter":
+		step, err := p.step()
+		if err != nil {
+			p.err = err.Error()
+			return p, nil
+		}
+		mainModel.log.Printf("Adding custom step %q to stage %s", step.Name, step.Stage)
+		mainModel.customSteps = append(mainModel.customSteps, step)
+		p.stopAdding()
+		p.cursor = len(mainModel.customSteps)
+		return p, nil
+	}
+
+	input := p.inputs()[p.focused]
+	*input, cmd = input.Update(msg)
+	return p, cmd
+}
+
+// step builds the step from the inputs, checking it makes sense
+func (p *customStagesPage) step() (stageStep, error) {
+	step := stageStep{
+		Stage: strings.TrimSpace(p.stageInput.Value()),
+		Name:  strings.TrimSpace(p.nameInput.Value()),
+	}
+	if err := validateStageName(step.Stage); err != nil {
+		return step, err
+	}
+	if step.Name == "" {
+		return step, fmt.Errorf("the step needs a name")
+	}
+	// Kept whole as a single command, the shell runs it, splitting it would break loops and conditionals
+	if command := strings.TrimSpace(p.commandsInput.Value()); command != "" {
+		step.Commands = []string{command}
+	}
+	if path := strings.TrimSpace(p.pathInput.Value()); path != "" {
+		if !strings.HasPrefix(path, "/") {
+			return step, fmt.Errorf("file path %s must be absolute", path)
+		}
+		// Typed on a single line, \n stands for a line break
+		step.Files = append(step.Files, stageFile{Path: path, Content: strings.ReplaceAll(p.contentInput.Value(), `\n`, "\n")})
+	}
+	if len(step.Commands) == 0 && len(step.Files) == 0 {
+		return step, fmt.Errorf("the step needs commands or a file to write")
+	}
+	return step, nil
+}
+
+func (p *customStagesPage) View() string {
+	s := "Custom Stages\n\n"
+	if p.adding {
+		s += fieldLabel("Stage:", p.focused == 0) + "\n" + p.stageInput.View() + "\n\n"
+		s += fieldLabel("Step name:", p.focused == 1) + "\n" + p.nameInput.View() + "\n\n"
+		s += fieldLabel("Shell command (; or && to run several):", p.focused == 2) + "\n" + inputView(p.commandsInput) + "\n\n"
+		s += fieldLabel("File to write (optional):", p.focused == 3) + "\n" + p.pathInput.View() + "\n\n"
+		s += fieldLabel(`File content (\n for line breaks):`, p.focused == 4) + "\n" + inputView(p.contentInput) + "\n"
+		if p.err != "" {
+			s += "\n" + lipgloss.NewStyle().Foreground(kairosHighlight2).Render(p.err) + "\n"
+		}
+		return s
+	}
+
+	s += "Steps run by the installed system, on top of the ones the installer sets up:\n\n"
+	dim := lipgloss.NewStyle().Faint(true)
+	total := len(mainModel.customSteps) + 1
+	for i, step := range mainModel.customSteps {
+		line := fmt.Sprintf("%s: %s", step.Stage, step.Name)
+		line += " " + dim.Render(fmt.Sprintf("(%d commands, %d files)", len(step.Commands), len(step.Files)))
+		s += listItem(i, p.cursor, total, line) + "\n"
+	}
+	s += listItem(len(mainModel.customSteps), p.cursor, total, "+ Add step") + "\n"
+	s += "\n" + lipgloss.NewStyle().Foreground(kairosHighlight2).Render(
+		fmt.Sprintf("%s Expert option: the steps run as root as given, a broken one can keep the system from booting.", glyphs.Warning),
+	)
+	return s
+}
+
+func (p *customStagesPage) Title() string {
+	return "Custom Stages"
+}
+
+func (p *customStagesPage) Help() string {
+	if p.adding {
+		return "tab/shift+tab: switch fields • tab: complete stage • enter: add step"
+	}
+	return "↑/k: up • ↓/j: down • enter/a: add step • d: delete step"
+}
+
+func (p *customStagesPage) ID() string { return "custom_stages" }
+
+func (p *customStagesPage) BackTarget() string { return "customization" }
+
+func (p *customStagesPage) TakingTextInput() bool { return p.adding }
+
+// HasUnsavedInput reports whether a step is being typed in
+func (p *customStagesPage) HasUnsavedInput() bool {
+	if !p.adding {
+		return false
+	}
+	for _, input := range p.inputs() {
+		if input.Value() != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *customStagesPage) DiscardInput() { p.stopAdding() }
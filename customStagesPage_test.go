@@ -0,0 +1,21 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCustomStepKeepsShellCommand(t *testing.T) {
+	p := newCustomStagesPage()
+	p.stageInput.SetValue("boot")
+	p.nameInput.SetValue("loop")
+	p.commandsInput.SetValue(" for i in a b; do echo $i; done ")
+
+	step, err := p.step()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"for i in a b; do echo $i; done"}; !slices.Equal(step.Commands, want) {
+		t.Errorf("commands %q, want %q", step.Commands, want)
+	}
+}
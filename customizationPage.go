@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -16,65 +17,182 @@ type YAMLPrompt struct {
 	YAMLSection string
 	Bool        bool
 	Prompt      string
+	Description string // Longer help text shown dimmed below the prompt
 	Default     string
 	AskFirst    bool
 	AskPrompt   string
 	IfEmpty     string
 	PlaceHolder string
+	Validation  string   // Name of a built-in validator (ipv4, email, url, port) or a regular expression
+	Type        string   // string, bool, int, choice or multichoice. Defaults to string, bool if Bool is set or choice if Choices are given
+	Min         *int     // Lower bound for int prompts
+	Max         *int     // Upper bound for int prompts
+	Choices     []string // Options for choice and multichoice prompts
+	// DependsOn is the section of an earlier prompt this one depends on. The prompt is only offered when that
+	// section is set to DependsOnValue, or to anything but false when DependsOnValue is empty.
+	DependsOn      string
+	DependsOnValue string
+}
+
+// Prompt types
+const (
+	PromptTypeString = "string"
+	PromptTypeBool   = "bool"
+	PromptTypeInt    = "int"
+	PromptTypeChoice = "choice"
+	PromptTypeMulti  = "multichoice"
+)
+
+// promptType returns the type of the prompt, honoring the older Bool field
+func promptType(prompt YAMLPrompt) string {
+	if prompt.Type != "" {
+		return prompt.Type
+	}
+	if prompt.Bool {
+		return PromptTypeBool
+	}
+	if len(prompt.Choices) > 0 {
+		return PromptTypeChoice
+	}
+	return PromptTypeString
 }
 
 type EventPayload struct {
 	Config string `json:"config"`
 }
 
-// Discover and run plugins for customization
-func runCustomizationPlugins() ([]YAMLPrompt, error) {
+// InstallStep is an extra step a plugin adds to the install, so the progress accounts for its work
+type InstallStep struct {
+	Name   string `json:"name"`   // Shown in the progress
+	Log    string `json:"log"`    // Installer output line marking the start of the step
+	After  string `json:"after"`  // Step it comes after. Right before the install completes if empty or unknown
+	Weight int    `json:"weight"` // Relative duration, see defaultStepWeights. 1 if unset
+}
+
+// pluginResponse is the answer of a plugin to the customization event
+type pluginResponse struct {
+	Prompts []YAMLPrompt  `json:"prompts"`
+	Steps   []InstallStep `json:"steps"`
+}
+
+// UnmarshalJSON also accepts a bare list of prompts, as older plugins answer
+func (r *pluginResponse) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &r.Prompts); err == nil {
+		return nil
+	}
+	type plain pluginResponse
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+// pluginsLoadedMsg carries what the customization plugins answered
+type pluginsLoadedMsg struct {
+	response pluginResponse
+	err      error
+}
+
+// loadCustomizationPlugins asks the plugins for their prompts in the background, as retrying until they
+// are registered can take a few seconds
+func loadCustomizationPlugins() tea.Cmd {
+	return func() tea.Msg {
+		response, err := runCustomizationPlugins()
+		return pluginsLoadedMsg{response: response, err: err}
+	}
+}
+
+// Discover and run plugins for customization. It blocks while retrying, so it runs in a tea.Cmd and
+// leaves applying the response to the Update loop.
+func runCustomizationPlugins() (pluginResponse, error) {
 	Manager.Initialize()
-	var r []YAMLPrompt
+	var r pluginResponse
 	Manager.Response("agent.interactive-install", func(p *pluggable.Plugin, resp *pluggable.EventResponse) {
-		err := json.Unmarshal([]byte(resp.Data), &r)
+		var response pluginResponse
+		err := json.Unmarshal([]byte(resp.Data), &response)
 		if err != nil {
-			fmt.Println(err)
+			mainModel.log.Printf("Unreadable response from plugin %s: %v", p.Name, err)
+		}
+		r.Prompts = response.Prompts
+		if len(response.Steps) > 0 {
+			mainModel.log.Printf("Plugin %s adds install steps: %v", p.Name, response.Steps)
+			r.Steps = response.Steps
 		}
 	})
 
-	_, err := Manager.Publish("agent.interactive-install", EventPayload{})
-	if err != nil {
-		return r, err
+	// Plugins can be slow to register, so retry for a bit before giving up
+	delay := publishRetryDelay
+	deadline := time.Now().Add(publishRetryTimeout)
+	for attempt := 1; ; attempt++ {
+		_, err := Manager.Publish("agent.interactive-install", EventPayload{})
+		if err == nil {
+			return r, nil
+		}
+		if time.Now().Add(delay).After(deadline) {
+			mainModel.log.Printf("Publishing to plugins failed after %d attempts: %v", attempt, err)
+			return r, err
+		}
+		mainModel.log.Printf("Publishing to plugins failed (attempt %d), retrying in %s: %v", attempt, delay, err)
+		time.Sleep(delay)
+		delay *= 2
 	}
+}
 
-	return r, nil
+// Retry policy for publishing the customization event to the plugins
+const (
+	publishRetryDelay   = 250 * time.Millisecond // Before the first retry, doubled on every attempt
+	publishRetryTimeout = 5 * time.Second        // Give up once retrying would go past this
+)
 
+// customizationBuiltinOptions are the options always offered in the customization menu, by page ID
+var customizationBuiltinOptions = []struct {
+	label       string
+	pageID      string
+	description string // Shown in the details pane while highlighted
+}{
+	{"Users", "users", "The accounts to create on the installed system, with their password, groups, login shell and sudo rights. The first one is the primary user."},
+	{"SSH Keys", "ssh_keys", "Public keys allowed to log in as each user. github:USER and gitlab:USER fetch the keys published on those sites."},
+	{"Hostname", "hostname", "The name of the installed system on the network."},
+	{"Kernel Arguments", "kernel_args", "Extra arguments for the kernel command line of the installed system, e.g. a serial console."},
+	{"Filesystem", "filesystem", "The filesystem of the persistent partition, where all the data of the system lives."},
+	{"Persistent Partition Size", "persistent_size", "How much of the disk the persistent partition takes, the rest of the disk by default."},
+	{"Offline Image Mirror", "image_mirror", "Install the OS image from a registry on the local network, for installs without internet access."},
+	{"Custom Stages", "custom_stages", "Expert option: add commands and files to any stage the installed system runs, e.g. to provision it on first boot."},
+	{"Base Config from URL", "config_url", "Start from a cloud config served by a config server, the answers given here are applied on top of it."},
 }
 
 func newCustomizationPage() *customizationPage {
 	return &customizationPage{
-		options: []string{
-			"User & Password",
-			"SSH Keys",
-		},
-
-		cursor: 0,
-		cursorWithIds: map[int]string{
-			0: "user_password",
-			1: "ssh_keys",
-		},
+		cursor:        0,
+		cursorWithIds: map[int]string{},
+		prompts:       map[string]YAMLPrompt{},
 	}
 }
 
-func checkPageExists(pageID string, options map[int]string) bool {
-	for _, opt := range options {
-		if strings.Contains(opt, pageID) {
-			return true
-		}
-	}
-	return false
-}
-
 type customizationPage struct {
 	cursor        int
+	loading       bool // Waiting for the plugins to answer
 	options       []string
 	cursorWithIds map[int]string
+	prompts       map[string]YAMLPrompt // Plugin prompts by page ID
+	promptOrder   []string              // Plugin prompt page IDs in the order the plugins returned them
+}
+
+// Details describes the highlighted option, plugin options with the description of their prompt
+func (p *customizationPage) Details() string {
+	pageID := p.cursorWithIds[p.cursor]
+	if prompt, ok := p.prompts[pageID]; ok {
+		if prompt.Description != "" {
+			return prompt.Description
+		}
+		return prompt.Prompt
+	}
+	for _, opt := range customizationBuiltinOptions {
+		if opt.pageID == pageID {
+			return opt.description
+		}
+	}
+	if pageID == "summary" {
+		return "Review all the answers before going ahead."
+	}
+	return ""
 }
 
 func (p *customizationPage) Title() string {
@@ -82,50 +200,103 @@ func (p *customizationPage) Title() string {
 }
 
 func (p *customizationPage) Help() string {
-	return genericNavigationHelp
+	return genericNavigationHelp + " • c: clear value"
 }
 
 func (p *customizationPage) Init() tea.Cmd {
-	mainModel.log.Printf("Running customization plugins...")
-	yaML, err := runCustomizationPlugins()
-	if err != nil {
-		mainModel.log.Printf("Error running customization plugins: %v", err)
-		fmt.Println("Error running customization plugins:", err)
+	p.buildOptions()
+	if p.loading {
 		return nil
 	}
-	if len(yaML) > 0 {
-		startIdx := len(p.options)
-		for i, prompt := range yaML {
-			// Check if its already added to the options!
-			if checkPageExists(idFromSection(prompt), p.cursorWithIds) {
-				mainModel.log.Printf("Customization page for %s already exists, skipping", prompt.YAMLSection)
-				continue
-			}
-			optIdx := startIdx + i
-			if prompt.Bool == false {
-				p.options = append(p.options, fmt.Sprintf("Configure %s", prompt.YAMLSection))
-				pageID := idFromSection(prompt)
-				p.cursorWithIds[optIdx] = pageID
-				newPage := newGenericQuestionPage(prompt)
-				mainModel.pages = append(mainModel.pages, newPage)
-			} else {
-				p.options = append(p.options, fmt.Sprintf("Configure %s", prompt.YAMLSection))
-				pageID := idFromSection(prompt)
-				p.cursorWithIds[optIdx] = pageID
-				newPage := newGenericBoolPage(prompt)
-				mainModel.pages = append(mainModel.pages, newPage)
+	mainModel.log.Printf("Running customization plugins...")
+	p.loading = true
+	return loadCustomizationPlugins()
+}
+
+// pluginsLoaded adds the prompts and install steps the plugins answered with
+func (p *customizationPage) pluginsLoaded(msg pluginsLoadedMsg) {
+	p.loading = false
+	if msg.err != nil {
+		mainModel.log.Printf("Error running customization plugins: %v", msg.err)
+	}
+	if len(msg.response.Steps) > 0 {
+		mainModel.pluginSteps = msg.response.Steps
+	}
+	for _, prompt := range msg.response.Prompts {
+		// Check if its already added to the options!
+		pageID := idFromSection(prompt)
+		if _, ok := p.prompts[pageID]; ok {
+			mainModel.log.Printf("Customization page for %s already exists, skipping", prompt.YAMLSection)
+			continue
+		}
+		p.prompts[pageID] = prompt
+		p.promptOrder = append(p.promptOrder, pageID)
+	}
+
+	p.buildOptions()
+	mainModel.log.Printf("Customization options loaded: %v", p.cursorWithIds)
+}
+
+// buildOptions rebuilds the menu, only offering the plugin prompts whose dependencies are satisfied.
+// It runs every time the page is shown, so answers given in the meantime are taken into account.
+func (p *customizationPage) buildOptions() {
+	p.options = []string{}
+	p.cursorWithIds = map[int]string{}
+	for _, opt := range customizationBuiltinOptions {
+		if mainModel.disabledPages[opt.pageID] {
+			continue
+		}
+		p.cursorWithIds[len(p.options)] = opt.pageID
+		p.options = append(p.options, opt.label)
+	}
+
+	for _, pageID := range p.promptOrder {
+		prompt := p.prompts[pageID]
+		if mainModel.disabledPages[pageID] {
+			continue
+		}
+		if !dependencySatisfied(prompt) {
+			// Drop answers to questions that no longer apply, so they don't end up in the config
+			if _, set := valueForSectionInMainModel(prompt.YAMLSection); set {
+				mainModel.log.Printf("Dependency %s not satisfied anymore, clearing %s", prompt.DependsOn, prompt.YAMLSection)
+				clearValueForSectionInMainModel(prompt.YAMLSection)
+				replacePage(newPromptPage(prompt))
 			}
+			continue
+		}
+		p.cursorWithIds[len(p.options)] = pageID
+		p.options = append(p.options, fmt.Sprintf("Configure %s", prompt.YAMLSection))
+		if !pageExists(pageID) {
+			mainModel.pages = append(mainModel.pages, newPromptPage(prompt))
 		}
 	}
 
 	// Now add the finish and install options to the bottom of the list
-	if !checkPageExists("summary", p.cursorWithIds) {
+	p.cursorWithIds[len(p.options)] = "summary"
+	if mainModel.rescue {
+		p.options = append(p.options, "Finish Customization and apply to the existing installation")
+	} else {
 		p.options = append(p.options, "Finish Customization and start Installation")
-		p.cursorWithIds[len(p.cursorWithIds)] = "summary"
 	}
 
-	mainModel.log.Printf("Customization options loaded: %v", p.cursorWithIds)
-	return nil
+	if p.cursor >= len(p.options) {
+		p.cursor = len(p.options) - 1
+	}
+}
+
+// dependencySatisfied reports whether the prompt should be offered given the answers so far
+func dependencySatisfied(prompt YAMLPrompt) bool {
+	if prompt.DependsOn == "" {
+		return true
+	}
+	value, set := valueForSectionInMainModel(prompt.DependsOn)
+	if !set {
+		return false
+	}
+	if prompt.DependsOnValue == "" {
+		return fmt.Sprintf("%v", value) != "false"
+	}
+	return fmt.Sprintf("%v", value) == prompt.DependsOnValue
 }
 
 func (p *customizationPage) Update(msg tea.Msg) (Page, tea.Cmd) {
@@ -144,6 +315,13 @@ func (p *customizationPage) Update(msg tea.Msg) (Page, tea.Cmd) {
 			if pageID, ok := p.cursorWithIds[p.cursor]; ok {
 				return p, func() tea.Msg { return GoToPageMsg{PageID: pageID} }
 			}
+		case "c":
+			// Clear the value of a plugin provided field and reset its page
+			if prompt, ok := p.prompts[p.cursorWithIds[p.cursor]]; ok {
+				mainModel.log.Println("Clearing value for section:", prompt.YAMLSection)
+				clearValueForSectionInMainModel(prompt.YAMLSection)
+				replacePage(newPromptPage(prompt))
+			}
 		}
 	}
 	return p, nil
@@ -153,43 +331,162 @@ func (p *customizationPage) View() string {
 	s := "Customization Options\n\n"
 	s += "Configure additional settings:\n\n"
 
+	tick := lipgloss.NewStyle().Foreground(kairosAccent).Render(glyphs.Check)
+	pending := lipgloss.NewStyle().Foreground(kairosHighlight2).Render(glyphs.Pending)
+	dim := lipgloss.NewStyle().Faint(true)
 	for i, option := range p.options {
-		cursor := " "
-		if p.cursor == i {
-			cursor = lipgloss.NewStyle().Foreground(kairosAccent).Render(">")
-		}
-		tick := ""
-		if option == "User & Password" {
-			// User & Password
-			if p.isUserConfigured() {
-				tick = lipgloss.NewStyle().Foreground(kairosAccent).Render(checkMark)
+		line := option
+		if preview, configured := p.status(p.cursorWithIds[i]); configured {
+			if p.cursorWithIds[i] == "ssh_keys" && pendingKeys(mainModel.users) > 0 {
+				line += " " + pending
+			} else {
+				line += " " + tick
 			}
-		}
-		if option == "SSH Keys" {
-			// SSH Keys
-			if p.isSSHConfigured() {
-				tick = lipgloss.NewStyle().Foreground(kairosAccent).Render(checkMark)
+			if preview != "" {
+				line += " " + dim.Render("("+preview+")")
 			}
 		}
-		s += fmt.Sprintf("%s %s %s\n", cursor, option, tick)
+		s += listItem(i, p.cursor, len(p.options), line) + "\n"
+	}
+	if p.loading {
+		s += "\n" + dim.Render("Asking the plugins for more options...") + "\n"
 	}
 
 	return s
 }
 
-// Helper methods to check configuration
-func (p *customizationPage) isUserConfigured() bool {
-	if &mainModel != nil {
-		return mainModel.username != "" && mainModel.password != ""
+// status returns whether the option for the given page ID has been configured and a short preview of its value
+func (p *customizationPage) status(pageID string) (string, bool) {
+	switch pageID {
+	case "users":
+		if !p.isUserConfigured() {
+			return "", false
+		}
+		if len(mainModel.users) == 1 {
+			return fmt.Sprintf("%s / %s", mainModel.users[0].Name, maskSecret(mainModel.users[0].Password)), true
+		}
+		return fmt.Sprintf("%d users", len(mainModel.users)), true
+	case "ssh_keys":
+		if !p.isSSHConfigured() {
+			return "", false
+		}
+		keys := 0
+		for _, u := range mainModel.users {
+			if len(u.SSHKeys) == 1 && len(mainModel.users) == 1 {
+				return truncate(u.SSHKeys[0], previewLength), true
+			}
+			keys += len(u.SSHKeys)
+		}
+		preview := fmt.Sprintf("%d keys", keys)
+		if pending := pendingKeys(mainModel.users); pending > 0 {
+			preview += fmt.Sprintf(", %d pending", pending)
+		}
+		return preview, true
+	case "config_url":
+		if mainModel.baseConfigURL == "" {
+			return "", false
+		}
+		return truncate(mainModel.baseConfigURL, previewLength), true
+	case "kernel_args":
+		if mainModel.kernelArgs == "" {
+			return "", false
+		}
+		return truncate(mainModel.kernelArgs, previewLength), true
+	case "filesystem":
+		return mainModel.filesystem, mainModel.filesystem != ""
+	case "custom_stages":
+		if len(mainModel.customSteps) == 0 {
+			return "", false
+		}
+		return fmt.Sprintf("%d steps", len(mainModel.customSteps)), true
+	case "image_mirror":
+		if mainModel.imageMirror == "" {
+			return "", false
+		}
+		return truncate(mainModel.imageMirror, previewLength), true
+	case "persistent_size":
+		if mainModel.persistentSize == 0 {
+			return "", false
+		}
+		return humanSize(mainModel.persistentSize * 1024 * 1024), true
+	case "hostname":
+		value, set := valueForSectionInMainModel(hostnameSection)
+		if !set {
+			return "", false
+		}
+		return fmt.Sprintf("%v", value), true
+	}
+	if prompt, ok := p.prompts[pageID]; ok {
+		// Plugin provided fields
+		value, set := valueForSectionInMainModel(prompt.YAMLSection)
+		if !set {
+			return "", false
+		}
+		preview := fmt.Sprintf("%v", value)
+		if isSecretSection(prompt.YAMLSection) {
+			return maskSecret(preview), true
+		}
+		return truncate(preview, previewLength), true
+	}
+	return "", false
+}
+
+// previewLength is how many characters of a value are shown in the customization menu
+const previewLength = 30
+
+// truncate shortens s to at most n characters, adding an ellipsis if it was cut
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
+
+// maskSecret hides a secret value, without leaking its length
+func maskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "******"
+}
+
+// isSecretSection guesses from its name whether a config section holds a secret
+func isSecretSection(section string) bool {
+	section = strings.ToLower(section)
+	for _, word := range []string{"password", "passwd", "secret", "token"} {
+		if strings.Contains(section, word) {
+			return true
+		}
 	}
 	return false
 }
 
+// Helper methods to check configuration
+func (p *customizationPage) isUserConfigured() bool {
+	return len(mainModel.users) > 0
+}
+
 func (p *customizationPage) isSSHConfigured() bool {
-	if &mainModel != nil {
-		return len(mainModel.sshKeys) > 0
+	for _, u := range mainModel.users {
+		if len(u.SSHKeys) > 0 {
+			return true
+		}
 	}
 	return false
 }
 
 func (p *customizationPage) ID() string { return "customization" }
+
+// BackTarget is the install options, or the rescue page the customization was started from in rescue mode
+func (p *customizationPage) BackTarget() string {
+	if mainModel.rescue {
+		return "rescue"
+	}
+	return "install_options"
+}
+
+// AutoAdvance finishes the customization with what was configured so far
+func (p *customizationPage) AutoAdvance() tea.Cmd {
+	return func() tea.Msg { return GoToPageMsg{PageID: "summary"} }
+}
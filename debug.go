@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// debugNavView shows the navigation stack and its depth in the top right corner, with --debug. The
+// oldest pages are cut when it doesn't fit, the current one matters most.
+func debugNavView() string {
+	width := mainModel.width - 6
+	path := append(append([]string{}, mainModel.navigationStack...), mainModel.currentPageID)
+	s := strings.Join(path, " > ")
+	if r := []rune(s); len(r) > width-12 {
+		s = "..." + string(r[len(r)-max(width-15, 1):])
+	}
+	s = fmt.Sprintf("depth %d: %s", len(mainModel.navigationStack), s)
+	return lipgloss.NewStyle().Faint(true).Width(width).Align(lipgloss.Right).Render(s)
+}
+
+// debugPanelWidth is the width of the state panel shown next to the page content with --debug, when the
+// terminal is not wide enough for the side column of the wide layout
+const debugPanelWidth = 34
+
+// debugPanelShown reports whether the state panel fits, it is left out on narrow terminals
+func debugPanelShown() bool {
+	return mainModel.debug && mainModel.width >= 100
+}
+
+// debugPanel shows the internal state worth knowing when diagnosing navigation and plugin issues.
+// Passwords are masked, and only the keys of extraFields are shown as their values may be secrets.
+func debugPanel() string {
+	users := make([]string, 0, len(mainModel.users))
+	for _, u := range mainModel.users {
+		user := u.Name
+		if u.Password != "" {
+			user += " " + maskSecret(u.Password)
+		}
+		users = append(users, user)
+	}
+	keys := make([]string, 0, len(mainModel.extraFields))
+	for key := range mainModel.extraFields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := []string{
+		"page: " + mainModel.currentPageID,
+		"stack: " + strings.Join(mainModel.navigationStack, ", "),
+		"disk: " + mainModel.disk,
+		"users: " + strings.Join(users, ", "),
+		"extraFields: " + strings.Join(keys, ", "),
+		fmt.Sprintf("plugin steps: %d", len(mainModel.pluginSteps)),
+		fmt.Sprintf("terminal: %dx%d", mainModel.width, mainModel.height),
+	}
+	return sidePanel("Debug", lipgloss.NewStyle().Faint(true).Render(strings.Join(lines, "\n")))
+}
+
+// enableDebug turns on --debug, adding the source of each line to the log to find what logged it
+func enableDebug() {
+	mainModel.debug = true
+	mainModel.log.SetFlags(log.LstdFlags | log.Lmicroseconds | log.Lshortfile)
+	mainModel.log.Printf("Debug mode enabled")
+}
+
+// debugf logs only with --debug, for the chatty details that would drown the regular log
+func debugf(format string, args ...any) {
+	if mainModel.debug {
+		_ = mainModel.log.Output(2, "DEBUG "+fmt.Sprintf(format, args...))
+	}
+}
+
+// debugMsg logs the messages that drive navigation. Text typed into an input is not logged, it may be
+// a password.
+func debugMsg(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyRunes && takingTextInput(currentPage()) {
+			debugf("key: (typed text) on %s", mainModel.currentPageID)
+		} else {
+			debugf("key: %s on %s", msg.String(), mainModel.currentPageID)
+		}
+	case GoToPageMsg:
+		debugf("go to %s from %s, stack %v", msg.PageID, mainModel.currentPageID, mainModel.navigationStack)
+	case NextPageMsg:
+		debugf("next page from %s", mainModel.currentPageID)
+	}
+}
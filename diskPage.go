@@ -2,8 +2,14 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/jaypipes/ghw/pkg/block"
@@ -11,46 +17,499 @@ import (
 )
 
 type diskStruct struct {
-	id   int
-	name string
-	size string
+	id        int
+	name      string
+	size      string
+	sizeBytes uint64
+	byID      string // Stable /dev/disk/by-id/ link, if any
+	byPath    string // Stable /dev/disk/by-path/ link, if any
+	serial    string // Serial number reported by the disk, if any
+	wwn       string // World Wide Name reported by the disk, if any
+	bySerial  string // /dev/disk/by-id/ link built from the serial or WWN, if any
+
+	vendor     string
+	model      string
+	driveType  block.DriveType         // HDD, SSD...
+	controller block.StorageController // SCSI, NVMe, virtio...
+	removable  bool
+	health     diskHealth // SMART health, as far as it can be told
+	space      diskSpace  // Space used on its filesystems
+
+	partitions []*block.Partition // Existing partitions on the disk
+}
+
+// minDiskSize is the size under which disks are not offered for installation
+const minDiskSize = 1 * 1024 * 1024 * 1024
+
+// onlyRemovable reports whether all the disks found are removable, so the install may well go to the
+// USB stick the installer was booted from
+func onlyRemovable(disks []diskStruct) bool {
+	for _, d := range disks {
+		if !d.removable {
+			return false
+		}
+	}
+	return len(disks) > 0
+}
+
+// suitableDisks returns the disks that are a sensible default target: not removable, big enough and not failing
+func suitableDisks(disks []diskStruct) []diskStruct {
+	var suitable []diskStruct
+	for _, d := range disks {
+		if !d.removable && d.sizeBytes >= minDiskSize && d.health != healthFailing {
+			suitable = append(suitable, d)
+		}
+	}
+	return suitable
+}
+
+// PickDefaultDisk selects a sensible default target among the disks: the smallest suitable one, as
+// the bigger ones are more likely to hold data
+func PickDefaultDisk(disks []diskStruct) (diskStruct, bool) {
+	suitable := suitableDisks(disks)
+	if len(suitable) == 0 {
+		return diskStruct{}, false
+	}
+	best := suitable[0]
+	for _, d := range suitable[1:] {
+		if d.sizeBytes < best.sizeBytes {
+			best = d
+		}
+	}
+	return best, true
+}
+
+// humanSize formats a size in bytes as GiB
+func humanSize(bytes uint64) string {
+	return fmt.Sprintf("%.2f GiB", float64(bytes)/float64(1024*1024*1024))
+}
+
+// details describes the disk and its partitions, to tell similar disks apart
+func (d diskStruct) details() string {
+	rotational := "no"
+	if d.driveType == block.DriveTypeHDD {
+		rotational = "yes"
+	}
+	removable := "no"
+	if d.removable {
+		removable = "yes"
+	}
+	s := fmt.Sprintf("Vendor: %s • Model: %s\n", orUnknown(d.vendor), orUnknown(d.model))
+	s += fmt.Sprintf("Type: %s • Controller: %s • Rotational: %s • Removable: %s\n", d.driveType, d.controller, rotational, removable)
+	if len(d.partitions) == 0 {
+		return s + "No partitions"
+	}
+	s += "Partitions:"
+	for _, part := range d.partitions {
+		s += "\n  " + partitionSummary(part)
+	}
+	return s
+}
+
+// partitionSummary describes a partition in a line: name, size, filesystem, label and mount point
+func partitionSummary(part *block.Partition) string {
+	s := fmt.Sprintf("%s %s", part.Name, humanSize(part.SizeBytes))
+	if part.Type != "" {
+		s += " " + part.Type
+	}
+	if label := part.FilesystemLabel; label != "" && label != "unknown" {
+		s += fmt.Sprintf(" label=%s", label)
+	} else if part.Label != "" && part.Label != "unknown" {
+		s += fmt.Sprintf(" label=%s", part.Label)
+	}
+	if part.MountPoint != "" {
+		s += fmt.Sprintf(" mounted on %s", part.MountPoint)
+	}
+	return s
+}
+
+// kairosPartitionPrefix is the prefix of the partition and filesystem labels of a Kairos installation
+const kairosPartitionPrefix = "COS_"
+
+// kairosLabels returns the labels of the partitions that belong to an existing Kairos installation, if any
+func (d diskStruct) kairosLabels() []string {
+	var labels []string
+	for _, part := range d.partitions {
+		for _, label := range []string{part.FilesystemLabel, part.Label} {
+			if strings.HasPrefix(strings.ToUpper(label), kairosPartitionPrefix) {
+				labels = append(labels, label)
+				break
+			}
+		}
+	}
+	return labels
+}
+
+// diskNaming selects how the chosen disk is referenced in the install config
+type diskNaming int
+
+const (
+	diskNameStable diskNaming = iota // by-id/by-path link
+	diskNameSerial                   // by-id link matching the serial or WWN, for fleets of identical hardware
+	diskNameKernel                   // sda, nvme0n1... which can change across reboots
+)
+
+func (n diskNaming) String() string {
+	switch n {
+	case diskNameSerial:
+		return "serial/WWN"
+	case diskNameKernel:
+		return "kernel name"
+	default:
+		return "stable (by-id/by-path)"
+	}
+}
+
+// stableName returns the most stable identifier available for the disk, falling back to the kernel name
+func (d diskStruct) stableName() string {
+	if d.byID != "" {
+		return d.byID
+	}
+	if d.byPath != "" {
+		return d.byPath
+	}
+	return d.name
+}
+
+// device returns the name that should be written into the install config for this disk
+func (d diskStruct) device(naming diskNaming) string {
+	switch naming {
+	case diskNameKernel:
+		return d.name
+	case diskNameSerial:
+		if d.bySerial != "" {
+			return d.bySerial
+		}
+		mainModel.log.Printf("Disk %s does not report a serial or WWN, using its stable name", d.name)
+	}
+	return d.stableName()
+}
+
+// serialLink returns the by-id link that references the disk by its WWN or serial number
+func serialLink(links []string, serial, wwn string) string {
+	for _, link := range links {
+		if wwn != "" && filepath.Base(link) == "wwn-"+wwn {
+			return link
+		}
+	}
+	for _, link := range links {
+		if serial != "" && strings.HasSuffix(filepath.Base(link), "_"+serial) {
+			return link
+		}
+	}
+	return ""
+}
+
+// knownValue drops the placeholder ghw uses for values a disk doesn't report
+func knownValue(v string) string {
+	if v == "unknown" {
+		return ""
+	}
+	return v
+}
+
+// orUnknown returns the value or a placeholder for disks that don't report it
+func orUnknown(v string) string {
+	if v == "" {
+		return "n/a"
+	}
+	return v
+}
+
+// stableLinks maps kernel device names (sda, nvme0n1...) to the symlinks pointing at them in the given
+// /dev/disk/ subdirectory. Links are sorted in lexical order, preferring model/serial based names over wwn
+// ones as those are easier to recognize, so the first one is the preferred one.
+func stableLinks(dir string) map[string][]string {
+	links := map[string][]string{}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return links
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		wi, wj := strings.HasPrefix(names[i], "wwn-"), strings.HasPrefix(names[j], "wwn-")
+		if wi != wj {
+			return wj
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		link := filepath.Join(dir, name)
+		target, err := filepath.EvalSymlinks(link)
+		if err != nil {
+			continue
+		}
+		kernelName := filepath.Base(target)
+		links[kernelName] = append(links[kernelName], link)
+	}
+	return links
+}
+
+// firstLink returns the preferred link out of the ones found by stableLinks
+func firstLink(links []string) string {
+	if len(links) == 0 {
+		return ""
+	}
+	return links[0]
+}
+
+// disksScannedMsg is sent once a disk scan triggered from the disk selection page finishes
+type disksScannedMsg struct {
+	disks []diskStruct
+	err   error
+}
+
+// diskCache keeps the result of the last scan, as probing with ghw can be slow on machines with many devices
+var diskCache struct {
+	sync.Mutex
+	disks []diskStruct
+	valid bool
+}
+
+// cachedDisks returns the disks from the last scan, only probing the hardware if there is no cached
+// result or refresh is set
+func cachedDisks(refresh bool) ([]diskStruct, error) {
+	diskCache.Lock()
+	defer diskCache.Unlock()
+	if diskCache.valid && !refresh {
+		return diskCache.disks, nil
+	}
+	disks, err := scanDisks()
+	if err != nil {
+		return nil, err
+	}
+	diskCache.disks = disks
+	diskCache.valid = true
+	return disks, nil
+}
+
+// scanDisksCmd runs the disk scan in the background and reports back with a disksScannedMsg
+func scanDisksCmd(refresh bool) tea.Cmd {
+	return func() tea.Msg {
+		disks, err := cachedDisks(refresh)
+		return disksScannedMsg{disks: disks, err: err}
+	}
 }
 
 // Disk Selection Page
 type diskSelectionPage struct {
-	disks  []diskStruct
-	cursor int
+	noBackTarget
+
+	disks    []diskStruct
+	cursor   int
+	scanning bool  // A scan is in progress
+	err      error // Error from the last scan, if any
+	spinner  spinner.Model
+	naming   diskNaming // How the selected disk is referenced in the config
+	details  bool       // Show the details of the disk under the cursor
+
+	confirmRemovable bool // Asking to confirm installing to a removable disk
+
+	preselect        string // Disk to put the cursor on once scanned, from the kernel command line
+	preselectApplied bool
+	warning          string
 }
 
-func newDiskSelectionPage() *diskSelectionPage {
+// scanDisks probes the block devices and returns the ones suitable for installation
+func scanDisks() ([]diskStruct, error) {
 	bl, err := block.New(option.WithDisableTools(), option.WithNullAlerter())
 	if err != nil {
-		fmt.Printf("Error initializing block device info: %v\n", err)
-		return nil
+		return nil, err
 	}
 	var disks []diskStruct
+	byID := stableLinks("/dev/disk/by-id")
+	byPath := stableLinks("/dev/disk/by-path")
 
 	for _, disk := range bl.Disks {
-		if disk.Name == "loop0" || disk.Name == "ram0" || disk.Name == "sr0" || disk.Name == "zram0" || disk.SizeBytes < 1*1024*1024*1024 {
-			continue // Skip loop, ram, sr, zram devices, and skip disks smaller than 1 GiB
+		if mainModel.loopback != "" {
+			// Safe mode, nothing but the loop device can be installed to
+			if filepath.Join("/dev", disk.Name) != mainModel.loopback {
+				continue
+			}
+		} else if skippedDisk(disk) || disk.SizeBytes < minDiskSize {
+			continue // Skip loop, ram, sr, zram devices..., and skip disks smaller than minDiskSize
 		}
 		mainModel.log.Println("Found disk:", disk.Name, "with size:", disk.SizeBytes, "bytes")
-		disks = append(disks, diskStruct{name: filepath.Join("/dev", disk.Name), size: fmt.Sprintf("%.2f GiB", float64(disk.SizeBytes)/float64(1024*1024*1024)), id: len(disks)})
+		disks = append(disks, diskStruct{
+			name:      filepath.Join("/dev", disk.Name),
+			size:      humanSize(disk.SizeBytes),
+			sizeBytes: disk.SizeBytes,
+			id:        len(disks),
+			byID:      firstLink(byID[disk.Name]),
+			byPath:    firstLink(byPath[disk.Name]),
+			serial:    knownValue(disk.SerialNumber),
+			wwn:       knownValue(disk.WWN),
+			bySerial:  serialLink(byID[disk.Name], knownValue(disk.SerialNumber), knownValue(disk.WWN)),
+
+			vendor:     knownValue(disk.Vendor),
+			model:      knownValue(disk.Model),
+			driveType:  disk.DriveType,
+			controller: disk.StorageController,
+			removable:  disk.IsRemovable,
+			health:     readDiskHealth(filepath.Join("/dev", disk.Name)),
+			space:      readDiskSpace(disk.Partitions),
+
+			partitions: disk.Partitions,
+		})
+	}
+	return disks, nil
+}
+
+// skippedDisk reports whether the disk is a kind never offered for installation, going by its kernel name
+// or by ghw finding it virtual. Virtio and Xen disks in VMs are not virtual for ghw, they can still be picked.
+func skippedDisk(disk *block.Disk) bool {
+	if disk.DriveType == block.DriveTypeVirtual {
+		return true
+	}
+	for _, prefix := range currentBranding().SkipDiskPrefixes {
+		if strings.HasPrefix(disk.Name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// findDisk returns the index of the disk matching the given device, which can be a kernel name (sda or
+// /dev/sda) or any of its stable links
+func findDisk(disks []diskStruct, device string) (int, bool) {
+	if !strings.HasPrefix(device, "/") {
+		device = filepath.Join("/dev", device)
+	}
+	resolved, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		resolved = device
+	}
+	for i, d := range disks {
+		for _, name := range []string{d.name, d.byID, d.byPath, d.bySerial} {
+			if name != "" && (name == device || name == resolved) {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// verifyDisk checks the device to install to is still the disk that was selected, as it may have been
+// unplugged or the kernel names shuffled since. The stable links identify the disk, the size catches a
+// different disk plugged in its place. Without a selection, e.g. a disk from a profile, it only has to exist.
+func verifyDisk(device string, selected diskStruct) error {
+	resolved, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrDiskChanged, device, err)
 	}
+	if selected.name == "" {
+		return nil
+	}
+	want := selected.name
+	for _, link := range []string{selected.bySerial, selected.byID, selected.byPath} {
+		if link == "" {
+			continue
+		}
+		if want, err = filepath.EvalSymlinks(link); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrDiskChanged, link, err)
+		}
+		break
+	}
+	if resolved != want {
+		return fmt.Errorf("%w: %s is now %s, expected %s", ErrDiskChanged, device, resolved, want)
+	}
+	data, err := os.ReadFile(filepath.Join("/sys/class/block", filepath.Base(resolved), "size"))
+	if err != nil {
+		return nil // Not worth failing over, the links matched
+	}
+	sectors, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err == nil && sectors*512 != selected.sizeBytes {
+		return fmt.Errorf("%w: %s is now %s, it was %s", ErrDiskChanged, device, humanSize(sectors*512), selected.size)
+	}
+	return nil
+}
+
+func newDiskSelectionPage() *diskSelectionPage {
+	s := spinner.New(spinner.WithSpinner(glyphs.Spinner))
+	s.Style = lipgloss.NewStyle().Foreground(kairosAccent)
 
 	return &diskSelectionPage{
-		disks:  disks,
-		cursor: 0,
+		cursor:    0,
+		spinner:   s,
+		naming:    diskNameStable,
+		preselect: mainModel.preselectedDisk,
 	}
 }
 
 func (p *diskSelectionPage) Init() tea.Cmd {
-	return nil
+	p.scanning = true
+	return tea.Batch(p.spinner.Tick, scanDisksCmd(false))
 }
 
 func (p *diskSelectionPage) Update(msg tea.Msg) (Page, tea.Cmd) {
 	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		if !p.scanning {
+			// Let the spinner stop once the scan is done
+			return p, nil
+		}
+		var cmd tea.Cmd
+		p.spinner, cmd = p.spinner.Update(msg)
+		return p, cmd
+	case disksScannedMsg:
+		p.scanning = false
+		p.err = msg.err
+		if msg.err != nil {
+			mainModel.log.Printf("Error scanning disks: %v", msg.err)
+			return p, nil
+		}
+		if len(msg.disks) == 0 {
+			p.err = ErrNoDisks
+		}
+		// Keep the cursor on the same disk if it is still there
+		selected := ""
+		if p.cursor < len(p.disks) {
+			selected = p.disks[p.cursor].name
+		}
+		p.disks = msg.disks
+		p.cursor = 0
+		for i, disk := range p.disks {
+			if disk.name == selected {
+				p.cursor = i
+				break
+			}
+		}
+		if !p.preselectApplied {
+			p.preselectApplied = true
+			if p.preselect == "" {
+				if disk, ok := PickDefaultDisk(p.disks); ok {
+					p.cursor, _ = findDisk(p.disks, disk.name)
+				}
+			} else if i, ok := findDisk(p.disks, p.preselect); ok {
+				mainModel.log.Printf("Preselecting disk %s from the kernel command line", p.preselect)
+				p.cursor = i
+			} else {
+				mainModel.log.Printf("Disk %s from the kernel command line was not found", p.preselect)
+				p.warning = fmt.Sprintf("Disk %s requested on the kernel command line was not found", p.preselect)
+			}
+			if mainModel.auto && !mainModel.rescue {
+				return p, p.autoSelect()
+			}
+		}
+		return p, nil
 	case tea.KeyMsg:
+		if p.scanning {
+			// Ignore input until the disk list is stable again
+			return p, nil
+		}
+		if p.confirmRemovable {
+			switch msg.String() {
+			case "y", "Y":
+				mainModel.log.Printf("Installing to removable disk %s confirmed", p.disks[p.cursor].name)
+				return p, p.selectDisk(mainModel.skipConfirmation)
+			case "n", "N", "esc":
+				p.confirmRemovable = false
+			}
+			return p, nil
+		}
 		switch msg.String() {
 		case "up", "k":
 			if p.cursor > 0 {
@@ -60,40 +519,185 @@ func (p *diskSelectionPage) Update(msg tea.Msg) (Page, tea.Cmd) {
 			if p.cursor < len(p.disks)-1 {
 				p.cursor++
 			}
+		case "r":
+			p.scanning = true
+			mainModel.log.Printf("Rescanning disks")
+			return p, tea.Batch(p.spinner.Tick, scanDisksCmd(true))
+		case "s":
+			p.naming = (p.naming + 1) % 3
+		case "i":
+			p.details = !p.details
 		case "enter":
-			// Store selected disk in mainModel
-			if p.cursor >= 0 && p.cursor < len(p.disks) {
-				mainModel.disk = p.disks[p.cursor].name
-				mainModel.log.Printf("Selected disk: %s", mainModel.disk)
+			if mainModel.rescue {
+				return p, p.selectForRescue()
+			}
+			if p.cursor < len(p.disks) && onlyRemovable(p.disks) {
+				// Make sure this isn't the wrong device before going on
+				p.confirmRemovable = true
+				return p, nil
 			}
-			// Go to confirmation page
-			return p, func() tea.Msg { return GoToPageMsg{PageID: "install_options"} }
+			return p, p.selectDisk(mainModel.skipConfirmation)
 		}
 	}
 	return p, nil
 }
 
+// selectDisk stores the disk under the cursor as the install target and moves on, to the wipe confirmation
+// unless skipConfirmation is set
+func (p *diskSelectionPage) selectDisk(skipConfirmation bool) tea.Cmd {
+	p.confirmRemovable = false
+	// Store selected disk in mainModel
+	if p.cursor >= 0 && p.cursor < len(p.disks) {
+		mainModel.disk = p.disks[p.cursor].device(p.naming)
+		mainModel.selectedDisk = p.disks[p.cursor]
+		mainModel.wipeConfirmed = skipConfirmation
+		mainModel.log.Printf("Selected disk: %s", mainModel.disk)
+	}
+	mainModel.skippedConfirm = skipConfirmation
+	if skipConfirmation {
+		mainModel.log.Printf("Skipping disk wipe confirmation for %s as requested", mainModel.disk)
+		return func() tea.Msg { return GoToPageMsg{PageID: "install_options"} }
+	}
+	// Go to confirmation page
+	return func() tea.Msg { return GoToPageMsg{PageID: "confirmation"} }
+}
+
+// autoSelect picks the disk without interaction, when the choice is unambiguous: either it was
+// requested explicitly or it is the only suitable one. Otherwise it is left to the user, and the disk
+// they pick goes through the wipe confirmation as usual.
+func (p *diskSelectionPage) autoSelect() tea.Cmd {
+	if p.preselect == "" || p.warning != "" {
+		suitable := suitableDisks(p.disks)
+		if len(suitable) != 1 {
+			mainModel.log.Printf("Automatic install: %d suitable disks found, waiting for a selection", len(suitable))
+			p.warning = fmt.Sprintf("Automatic install needs exactly one suitable disk, found %d. Pick one to continue.", len(suitable))
+			return nil
+		}
+		p.cursor, _ = findDisk(p.disks, suitable[0].name)
+	}
+	mainModel.log.Printf("Automatic install: selecting %s", p.disks[p.cursor].name)
+	if onlyRemovable(p.disks) {
+		// Make sure this isn't the wrong device before going on
+		p.confirmRemovable = true
+		return nil
+	}
+	return p.selectDisk(true)
+}
+
+// selectForRescue picks the disk under the cursor to rescue, if it holds an installation
+func (p *diskSelectionPage) selectForRescue() tea.Cmd {
+	if p.cursor >= len(p.disks) {
+		return nil
+	}
+	disk := p.disks[p.cursor]
+	if len(disk.kairosLabels()) == 0 {
+		p.warning = fmt.Sprintf("No Kairos installation found on %s", disk.name)
+		return nil
+	}
+	p.warning = ""
+	mainModel.disk = disk.device(p.naming)
+	mainModel.selectedDisk = disk
+	mainModel.log.Printf("Selected disk to rescue: %s", mainModel.disk)
+	return func() tea.Msg { return GoToPageMsg{PageID: "rescue"} }
+}
+
 func (p *diskSelectionPage) View() string {
 	s := "Select target disk for installation:\n\n"
-	s += "WARNING: All data on the selected disk will be DESTROYED!\n\n"
+	s += fmt.Sprintf("%s WARNING: All data on the selected disk will be DESTROYED!\n\n", glyphs.Warning)
+	if mainModel.rescue {
+		s = "Select the disk holding the installation to rescue:\n\n"
+	}
 
+	if p.scanning {
+		return s + p.spinner.View() + " Scanning disks...\n"
+	}
+
+	if p.err != nil {
+		s += fmt.Sprintf("Error scanning disks: %v\n", p.err)
+		if hint := errorGuidance(p.err); hint != "" {
+			s += "\n" + hint + "\n"
+		}
+		return s
+	}
+
+	if mainModel.lastAttempt != nil {
+		style := lipgloss.NewStyle().Faint(true)
+		if !mainModel.lastAttempt.Success {
+			style = lipgloss.NewStyle().Foreground(kairosHighlight2)
+		}
+		s += style.Render(mainModel.lastAttempt.String()) + "\n\n"
+	}
+	if p.warning != "" {
+		s += lipgloss.NewStyle().Foreground(kairosHighlight2).Render(fmt.Sprintf("%s %s", glyphs.Warning, p.warning)) + "\n\n"
+	}
+	if onlyRemovable(p.disks) && !mainModel.rescue {
+		s += lipgloss.NewStyle().Foreground(kairosHighlight2).Bold(true).Render(
+			fmt.Sprintf("%s Only removable disks were found. Make sure you are not about to install onto the USB stick the installer runs from!", glyphs.Warning),
+		) + "\n\n"
+	}
+	if p.confirmRemovable {
+		s += lipgloss.NewStyle().
+			Border(glyphs.Border).
+			BorderForeground(kairosHighlight2).
+			Padding(0, 1).
+			Render(fmt.Sprintf("%s is removable. Install to it anyway? (y/n)", p.disks[p.cursor].name)) + "\n\n"
+	}
+
+	s += fmt.Sprintf("Config device name: %s\n\n", p.naming)
+
+	dim := lipgloss.NewStyle().Faint(true)
 	for i, disk := range p.disks {
-		cursor := " "
-		if p.cursor == i {
-			cursor = lipgloss.NewStyle().Foreground(kairosAccent).Render(">")
+		s += listItem(i, p.cursor, len(p.disks), fmt.Sprintf("%s (%s)", disk.name, disk.size)) + "\n"
+		if disk.stableName() != disk.name {
+			s += dim.Render(fmt.Sprintf("    %s", disk.stableName())) + "\n"
+		}
+		s += dim.Render(fmt.Sprintf("    Serial: %s • WWN: %s • Health: %s", orUnknown(disk.serial), orUnknown(disk.wwn), disk.health)) + "\n"
+		s += dim.Render(fmt.Sprintf("    Space: %s", disk.space)) + "\n"
+		if disk.health == healthFailing {
+			s += lipgloss.NewStyle().Foreground(kairosHighlight2).Bold(true).Render(
+				fmt.Sprintf("    %s SMART reports this disk as failing, installing to it is not recommended", glyphs.Warning),
+			) + "\n"
+		}
+		if p.details && i == p.cursor {
+			s += lipgloss.NewStyle().
+				Border(glyphs.Border).
+				BorderForeground(kairosBorder).
+				Padding(0, 1).
+				MarginLeft(4).
+				Render(disk.details()) + "\n"
 		}
-		s += fmt.Sprintf("%s %s (%s)\n", cursor, disk.name, disk.size)
 	}
 
 	return s
 }
 
+// Details describes the disk under the cursor, unless its details are already shown in the list
+func (p *diskSelectionPage) Details() string {
+	if p.scanning || p.details || p.cursor >= len(p.disks) {
+		return ""
+	}
+	return p.disks[p.cursor].details()
+}
+
 func (p *diskSelectionPage) Title() string {
 	return "Disk Selection"
 }
 
 func (p *diskSelectionPage) Help() string {
-	return genericNavigationHelp
+	if p.confirmRemovable {
+		return "y: install to the removable disk • n: pick another disk"
+	}
+	return genericNavigationHelp + " • r: rescan disks • s: toggle stable name • i: toggle details"
 }
 
 func (p *diskSelectionPage) ID() string { return "disk_selection" }
+
+// AutoAdvance selects the disk under the cursor, the one from the kernel command line if it was found.
+// It waits for the scan to finish and doesn't pick anything when no disk was found.
+func (p *diskSelectionPage) AutoAdvance() tea.Cmd {
+	if p.scanning || len(p.disks) == 0 {
+		return nil
+	}
+	_, cmd := p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return cmd
+}
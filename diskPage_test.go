@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io"
+	"log"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jaypipes/ghw/pkg/block"
+)
+
+func TestSkippedDisk(t *testing.T) {
+	oldBranding, oldDir := currentBranding, brandingDir
+	t.Cleanup(func() { currentBranding, brandingDir = oldBranding, oldDir })
+	brandingDir = func() string { return "" }
+
+	tests := []struct {
+		name     string
+		prefixes []string // Branding prefixes, the embedded default ones if nil
+		disk     block.Disk
+		skipped  bool
+	}{
+		{name: "first loop device", disk: block.Disk{Name: "loop0"}, skipped: true},
+		{name: "second loop device", disk: block.Disk{Name: "loop1"}, skipped: true},
+		{name: "two digit loop device", disk: block.Disk{Name: "loop12"}, skipped: true},
+		{name: "ram disk", disk: block.Disk{Name: "ram0"}, skipped: true},
+		{name: "cdrom", disk: block.Disk{Name: "sr0"}, skipped: true},
+		{name: "zram swap", disk: block.Disk{Name: "zram0"}, skipped: true},
+		{name: "device mapper", disk: block.Disk{Name: "dm-0"}, skipped: true},
+		{name: "software raid", disk: block.Disk{Name: "md127"}, skipped: true},
+		{name: "sata disk", disk: block.Disk{Name: "sda"}},
+		{name: "nvme disk", disk: block.Disk{Name: "nvme0n1"}},
+		{name: "virtio disk", disk: block.Disk{Name: "vda"}},
+		{name: "mmc card", disk: block.Disk{Name: "mmcblk0"}},
+		{name: "virtual for ghw", disk: block.Disk{Name: "xyz0", DriveType: block.DriveTypeVirtual}, skipped: true},
+		{name: "branding prefix", prefixes: []string{"nbd"}, disk: block.Disk{Name: "nbd0"}, skipped: true},
+		{name: "loop device offered by the branding", prefixes: []string{"sr"}, disk: block.Disk{Name: "loop0"}},
+		{name: "prefix in the middle", prefixes: []string{"da"}, disk: block.Disk{Name: "sda"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			currentBranding = loadBranding
+			if tt.prefixes != nil {
+				currentBranding = func() Branding { return Branding{SkipDiskPrefixes: tt.prefixes} }
+			}
+			if got := skippedDisk(&tt.disk); got != tt.skipped {
+				t.Errorf("skippedDisk(%s) = %v, want %v", tt.disk.Name, got, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestAutoSelectConfirmsManualPicks(t *testing.T) {
+	oldModel := mainModel
+	t.Cleanup(func() { mainModel = oldModel })
+
+	disk := func(name string) diskStruct { return diskStruct{name: name, sizeBytes: minDiskSize} }
+	tests := []struct {
+		name  string
+		disks []diskStruct
+		keys  []tea.KeyMsg // Sent once the disks are scanned
+		next  string
+	}{
+		{name: "only suitable disk", disks: []diskStruct{disk("/dev/vda")}, next: "install_options"},
+		{name: "picked by hand among several", disks: []diskStruct{disk("/dev/vda"), disk("/dev/vdb")}, keys: []tea.KeyMsg{{Type: tea.KeyEnter}}, next: "confirmation"},
+		{name: "picked by hand without a suitable one", disks: []diskStruct{{name: "/dev/vda"}}, keys: []tea.KeyMsg{{Type: tea.KeyEnter}}, next: "confirmation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mainModel = model{log: log.New(io.Discard, "", 0), auto: true}
+			p := newDiskSelectionPage()
+			p.Init()
+			_, cmd := p.Update(disksScannedMsg{disks: tt.disks})
+			for _, key := range tt.keys {
+				if cmd != nil {
+					t.Fatalf("disk selected before picking one")
+				}
+				_, cmd = p.Update(key)
+			}
+			if msgs := runCmd(cmd); len(msgs) != 1 || msgs[0] != (GoToPageMsg{PageID: tt.next}) {
+				t.Errorf("went to %#v, want %s", msgs, tt.next)
+			}
+			if mainModel.wipeConfirmed != (tt.next == "install_options") {
+				t.Errorf("wipe confirmed %v going to %s", mainModel.wipeConfirmed, tt.next)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import "errors"
+
+// Installer failures. They wrap the underlying error, so the cause can be told with errors.Is and the UI
+// can offer guidance matching it.
+var (
+	ErrNoDisks        = errors.New("no suitable disks found")
+	ErrDiskChanged    = errors.New("selected disk is gone or changed")
+	ErrConfigWrite    = errors.New("could not write the install config")
+	ErrPreHook        = errors.New("pre-install hook failed")
+	ErrInstallerStart = errors.New("could not start the installer")
+	ErrInstallerExit  = errors.New("installer failed")
+	ErrAborted        = errors.New("installation aborted by user")
+	ErrInstallTimeout = errors.New("installation timed out")
+)
+
+// errorGuidance returns a hint on what to do about the error, empty if there is nothing specific to say
+func errorGuidance(err error) string {
+	switch {
+	case errors.Is(err, ErrNoDisks):
+		return "Check the disks are connected and detected by the kernel (lsblk), disks under 1 GiB are not offered."
+	case errors.Is(err, ErrDiskChanged):
+		return "The disk was unplugged or the disks were renamed since it was picked. Nothing was written, start over to pick it again."
+	case errors.Is(err, ErrConfigWrite):
+		return "Check there is free space in " + installConfigPath() + "'s directory."
+	case errors.Is(err, ErrPreHook):
+		return "A hook from the branding refused to continue, its output above says why."
+	case errors.Is(err, ErrInstallerStart):
+		return "Make sure kairos-agent is installed and in the PATH."
+	case errors.Is(err, ErrInstallerExit):
+		return "The installer output above and /tmp/kairos-installer.log have the details."
+	case errors.Is(err, ErrInstallTimeout):
+		return "The installer was stopped as it seemed stuck, a failing disk is the usual suspect. Raise --install-timeout if the install is just slow."
+	case errors.Is(err, ErrAborted):
+		return "Nothing may be left in a usable state on the disk, install again before booting from it."
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// fakeInstallerOutput is what the fake installer prints, the lines kairos-agent logs when starting each step
+var fakeInstallerOutput = []string{
+	"Starting fake installation",
+	AgentPartitionLog + " /dev/fake",
+	AgentBeforeInstallLog,
+	AgentActiveLog + " /run/cos/state/cOS/active.img",
+	AgentBootloaderLog,
+	AgentRecoveryLog,
+	AgentPassiveLog,
+	AgentAfterInstallLog,
+	AgentCompleteLog,
+}
+
+// fakeRunner pretends to install, printing its lines one by one. It lets the install page be tried out
+// and its step tracking checked without touching any disk.
+type fakeRunner struct {
+	lines []string
+	delay time.Duration // Pause before each line
+	err   error         // What the install ends with
+}
+
+// newFakeRunner returns a fake installer printing the agent step lines, a line every delay
+func newFakeRunner(delay time.Duration) *fakeRunner {
+	return &fakeRunner{lines: fakeInstallerOutput, delay: delay}
+}
+
+// fakeProcess is a running fake install
+type fakeProcess struct {
+	output *io.PipeReader
+	done   chan struct{}
+	err    error
+}
+
+func (f *fakeRunner) Start(configPath string) (installerProcess, error) {
+	r, w := io.Pipe()
+	proc := &fakeProcess{output: r, done: make(chan struct{}), err: f.err}
+	go func() {
+		defer close(proc.done)
+		for _, line := range f.lines {
+			time.Sleep(f.delay)
+			if _, err := io.WriteString(w, line+"\n"); err != nil {
+				proc.err = err // Killed
+				return
+			}
+		}
+		w.Close()
+	}()
+	return proc, nil
+}
+
+func (f *fakeProcess) Output() io.Reader { return f.output }
+
+func (f *fakeProcess) Wait() error {
+	<-f.done
+	return f.err
+}
+
+func (f *fakeProcess) Kill() error {
+	return f.output.CloseWithError(errors.New("killed"))
+}
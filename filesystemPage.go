@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// supportedFilesystems are the filesystems kairos-agent can format the persistent partition with
+var supportedFilesystems = []string{"ext4", "xfs", "btrfs"}
+
+// validateFilesystem checks the filesystem is one the agent supports, empty leaves it to the agent default
+func validateFilesystem(fs string) error {
+	if fs == "" || slices.Contains(supportedFilesystems, fs) {
+		return nil
+	}
+	return fmt.Errorf("unsupported filesystem %s, use one of %s", fs, strings.Join(supportedFilesystems, ", "))
+}
+
+// Filesystem Page, picks the filesystem of the persistent partition, where all the data of the system lives
+type filesystemPage struct {
+	cursor int // 0 is the agent default, then supportedFilesystems
+}
+
+func newFilesystemPage() *filesystemPage {
+	return &filesystemPage{}
+}
+
+func (p *filesystemPage) Init() tea.Cmd {
+	p.cursor = slices.Index(supportedFilesystems, mainModel.filesystem) + 1
+	return nil
+}
+
+func (p *filesystemPage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if p.cursor > 0 {
+				p.cursor--
+			}
+		case "down", "j":
+			if p.cursor < len(supportedFilesystems) {
+				p.cursor++
+			}
+		case "enter":
+			mainModel.filesystem = ""
+			if p.cursor > 0 {
+				mainModel.filesystem = supportedFilesystems[p.cursor-1]
+			}
+			mainModel.log.Printf("Setting the persistent filesystem to %q", mainModel.filesystem)
+			return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+		}
+	}
+	return p, nil
+}
+
+func (p *filesystemPage) View() string {
+	s := "Filesystem\n\n"
+	s += "Filesystem for the persistent partition, holding the data and configuration of the system:\n\n"
+	options := append([]string{"Agent default"}, supportedFilesystems...)
+	for i, option := range options {
+		s += listItem(i, p.cursor, len(options), option) + "\n"
+	}
+	return s
+}
+
+func (p *filesystemPage) Title() string {
+	return "Filesystem"
+}
+
+func (p *filesystemPage) Help() string {
+	return genericNavigationHelp
+}
+
+func (p *filesystemPage) ID() string { return "filesystem" }
+
+func (p *filesystemPage) BackTarget() string { return "customization" }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -13,6 +14,7 @@ import (
 type genericQuestionPage struct {
 	genericInput textinput.Model
 	section      YAMLPrompt
+	err          error // Validation error for the last submitted value
 }
 
 func (g genericQuestionPage) Init() tea.Cmd {
@@ -25,13 +27,21 @@ func (g genericQuestionPage) Update(msg tea.Msg) (Page, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
+		case "up", "down":
+			fieldHistory("prompt:"+g.section.YAMLSection).recall(&g.genericInput, msg.String())
+			return g, nil
 		case "enter":
+			fieldHistory("prompt:" + g.section.YAMLSection).add(g.genericInput.Value())
 			if g.genericInput.Value() == "" && g.section.IfEmpty != "" {
 				// If the input is empty and IfEmpty is set, use IfEmpty value
 				g.genericInput.SetValue(g.section.IfEmpty)
 			}
 			// Now if the input is not empty, we can proceed
 			if g.genericInput.Value() != "" {
+				if g.err = validate(g.section.Validation, g.genericInput.Value()); g.err != nil {
+					mainModel.log.Println("Invalid value", g.genericInput.Value(), "for section:", g.section.YAMLSection, g.err)
+					return g, nil
+				}
 				mainModel.log.Println("Setting value", g.genericInput.Value(), "for section:", g.section.YAMLSection)
 				setValueForSectionInMainModel(g.genericInput.Value(), g.section.YAMLSection)
 				return g, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
@@ -47,9 +57,32 @@ func (g genericQuestionPage) Update(msg tea.Msg) (Page, tea.Cmd) {
 	return g, cmd
 }
 
+// savedValue returns the value last saved for the prompt, empty if none
+func (g genericQuestionPage) savedValue() string {
+	if value, set := valueForSectionInMainModel(g.section.YAMLSection); set {
+		return fmt.Sprintf("%v", value)
+	}
+	return ""
+}
+
+func (g genericQuestionPage) HasUnsavedInput() bool {
+	return g.genericInput.Value() != g.savedValue()
+}
+
+// DiscardInput replaces the page with a fresh one holding the saved value, the page is stored by value
+func (g genericQuestionPage) DiscardInput() {
+	page := newGenericQuestionPage(g.section)
+	page.genericInput.SetValue(g.savedValue())
+	replacePage(page)
+}
+
 func (g genericQuestionPage) View() string {
-	s := g.section.Prompt + "\n\n"
-	s += g.genericInput.View() + "\n\n"
+	s := promptHeader(g.section) + "\n\n"
+	s += inputView(g.genericInput) + "\n\n"
+
+	if g.err != nil {
+		s += lipgloss.NewStyle().Foreground(kairosHighlight2).Render("Invalid value: "+g.err.Error()) + "\n"
+	}
 
 	return s
 }
@@ -59,13 +92,28 @@ func (g genericQuestionPage) Title() string {
 }
 
 func (g genericQuestionPage) Help() string {
-	return "Press Enter to submit your answer, or esc to cancel."
+	return "Press Enter to submit your answer, ↑/↓ to recall earlier ones, or esc to cancel."
 }
 
 func (g genericQuestionPage) ID() string {
 	return idFromSection(g.section)
 }
 
+// BackTarget is the customization menu, where all the plugin prompts are asked from
+func (g genericQuestionPage) BackTarget() string { return "customization" }
+
+func (g genericQuestionPage) TakingTextInput() bool { return true }
+
+// promptHeader renders the prompt text, which may span several lines, wrapped to the content width,
+// followed by the dimmed description if the plugin gave one
+func promptHeader(section YAMLPrompt) string {
+	s := wrap(section.Prompt)
+	if section.Description != "" {
+		s += "\n" + lipgloss.NewStyle().Faint(true).Render(wrap(section.Description))
+	}
+	return s
+}
+
 func idFromSection(section YAMLPrompt) string {
 	// Generate a unique ID based on the section's YAMLSection.
 	// This could be a simple hash or just the section name.
@@ -86,6 +134,22 @@ func newGenericQuestionPage(section YAMLPrompt) *genericQuestionPage {
 	}
 }
 
+// newPromptPage creates the page matching the type of the given plugin prompt
+func newPromptPage(section YAMLPrompt) Page {
+	switch promptType(section) {
+	case PromptTypeBool:
+		return newGenericBoolPage(section)
+	case PromptTypeInt:
+		return newGenericIntPage(section)
+	case PromptTypeChoice:
+		return newGenericChoicePage(section)
+	case PromptTypeMulti:
+		return newGenericMultiChoicePage(section)
+	default:
+		return newGenericQuestionPage(section)
+	}
+}
+
 // genericBoolPage represents a page that asks a generic yes/no question
 type genericBoolPage struct {
 	cursor  int
@@ -113,6 +177,8 @@ func (g *genericBoolPage) ID() string {
 	return idFromSection(g.section)
 }
 
+func (g *genericBoolPage) BackTarget() string { return "customization" }
+
 func (g *genericBoolPage) Init() tea.Cmd {
 	return nil
 }
@@ -129,7 +195,12 @@ func (g *genericBoolPage) Update(msg tea.Msg) (Page, tea.Cmd) {
 			// in both cases we just go back to customization
 			// Save the value to mainModel.extraFields
 			mainModel.log.Println("Setting value", g.options[g.cursor], "for section:", g.section.YAMLSection)
-			setValueForSectionInMainModel(g.options[g.cursor], g.section.YAMLSection)
+			// Transform "Yes" to "true" and "No" to "false"
+			value := "false"
+			if g.cursor == 0 {
+				value = "true"
+			}
+			setValueForSectionInMainModel(value, g.section.YAMLSection)
 			return g, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
 		}
 	}
@@ -137,14 +208,287 @@ func (g *genericBoolPage) Update(msg tea.Msg) (Page, tea.Cmd) {
 }
 
 func (g *genericBoolPage) View() string {
-	s := g.section.Prompt + "\n\n"
+	s := promptHeader(g.section) + "\n\n"
 
 	for i, option := range g.options {
-		cursor := " "
-		if g.cursor == i {
-			cursor = lipgloss.NewStyle().Foreground(kairosAccent).Render(">")
+		s += listItem(i, g.cursor, len(g.options), option) + "\n"
+	}
+
+	return s
+}
+
+// genericIntPage represents a page that asks for a number, which can be stepped up and down
+type genericIntPage struct {
+	intInput textinput.Model
+	section  YAMLPrompt
+	err      error // Error for the last submitted value
+}
+
+// newGenericIntPage initializes a new generic number page, starting at the prompt default or lower bound
+func newGenericIntPage(section YAMLPrompt) *genericIntPage {
+	intInput := textinput.New()
+	intInput.Placeholder = section.PlaceHolder
+	intInput.Width = 20
+	intInput.Focus()
+
+	start := 0
+	if section.Min != nil {
+		start = *section.Min
+	}
+	if v, err := strconv.Atoi(section.Default); err == nil {
+		start = v
+	}
+	intInput.SetValue(strconv.Itoa(start))
+
+	return &genericIntPage{
+		intInput: intInput,
+		section:  section,
+	}
+}
+
+func (g *genericIntPage) Title() string {
+	return idFromSection(g.section)
+}
+
+func (g *genericIntPage) Help() string {
+	return "↑/+: increase • ↓/-: decrease • enter: submit"
+}
+
+func (g *genericIntPage) ID() string {
+	return idFromSection(g.section)
+}
+
+func (g *genericIntPage) BackTarget() string { return "customization" }
+
+func (g *genericIntPage) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// clamp keeps the value within the prompt bounds
+func (g *genericIntPage) clamp(v int) int {
+	if g.section.Min != nil && v < *g.section.Min {
+		return *g.section.Min
+	}
+	if g.section.Max != nil && v > *g.section.Max {
+		return *g.section.Max
+	}
+	return v
+}
+
+func (g *genericIntPage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "+":
+			v, _ := strconv.Atoi(g.intInput.Value())
+			g.intInput.SetValue(strconv.Itoa(g.clamp(v + 1)))
+			g.err = nil
+			return g, nil
+		case "down", "-":
+			v, _ := strconv.Atoi(g.intInput.Value())
+			g.intInput.SetValue(strconv.Itoa(g.clamp(v - 1)))
+			g.err = nil
+			return g, nil
+		case "enter":
+			v, err := strconv.Atoi(g.intInput.Value())
+			if err != nil {
+				g.err = fmt.Errorf("%q is not a number", g.intInput.Value())
+				return g, nil
+			}
+			if g.clamp(v) != v {
+				g.err = fmt.Errorf("value must be %s", g.bounds())
+				return g, nil
+			}
+			g.err = nil
+			mainModel.log.Println("Setting value", v, "for section:", g.section.YAMLSection)
+			setValueForSectionInMainModel(v, g.section.YAMLSection)
+			return g, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+		}
+		// Only digits can be typed in
+		if msg.Type == tea.KeyRunes {
+			for _, r := range msg.Runes {
+				if r < '0' || r > '9' {
+					return g, nil
+				}
+			}
+		}
+	}
+
+	g.intInput, cmd = g.intInput.Update(msg)
+	return g, cmd
+}
+
+// bounds describes the allowed range of values
+func (g *genericIntPage) bounds() string {
+	switch {
+	case g.section.Min != nil && g.section.Max != nil:
+		return fmt.Sprintf("between %d and %d", *g.section.Min, *g.section.Max)
+	case g.section.Min != nil:
+		return fmt.Sprintf("at least %d", *g.section.Min)
+	case g.section.Max != nil:
+		return fmt.Sprintf("at most %d", *g.section.Max)
+	}
+	return "a number"
+}
+
+func (g *genericIntPage) View() string {
+	s := promptHeader(g.section) + "\n\n"
+	s += inputView(g.intInput) + "\n\n"
+	if g.section.Min != nil || g.section.Max != nil {
+		s += fmt.Sprintf("Value must be %s\n", g.bounds())
+	}
+
+	if g.err != nil {
+		s += lipgloss.NewStyle().Foreground(kairosHighlight2).Render("Invalid value: "+g.err.Error()) + "\n"
+	}
+
+	return s
+}
+
+// genericChoicePage represents a page that asks to pick one of a fixed set of values
+type genericChoicePage struct {
+	cursor  int
+	section YAMLPrompt
+}
+
+func newGenericChoicePage(section YAMLPrompt) *genericChoicePage {
+	cursor := 0
+	for i, choice := range section.Choices {
+		if choice == section.Default {
+			cursor = i
+			break
+		}
+	}
+	return &genericChoicePage{
+		cursor:  cursor,
+		section: section,
+	}
+}
+
+func (g *genericChoicePage) Title() string {
+	return idFromSection(g.section)
+}
+
+func (g *genericChoicePage) Help() string {
+	return genericNavigationHelp
+}
+
+func (g *genericChoicePage) ID() string {
+	return idFromSection(g.section)
+}
+
+func (g *genericChoicePage) BackTarget() string { return "customization" }
+
+func (g *genericChoicePage) Init() tea.Cmd {
+	return nil
+}
+
+func (g *genericChoicePage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if g.cursor > 0 {
+				g.cursor--
+			}
+		case "down", "j":
+			if g.cursor < len(g.section.Choices)-1 {
+				g.cursor++
+			}
+		case "enter":
+			if g.cursor < len(g.section.Choices) {
+				mainModel.log.Println("Setting value", g.section.Choices[g.cursor], "for section:", g.section.YAMLSection)
+				setValueForSectionInMainModel(g.section.Choices[g.cursor], g.section.YAMLSection)
+			}
+			return g, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+		}
+	}
+	return g, nil
+}
+
+func (g *genericChoicePage) View() string {
+	s := promptHeader(g.section) + "\n\n"
+
+	for i, choice := range g.section.Choices {
+		s += listItem(i, g.cursor, len(g.section.Choices), choice) + "\n"
+	}
+
+	return s
+}
+
+// genericMultiChoicePage represents a page that asks to pick any number of values out of a fixed set
+type genericMultiChoicePage struct {
+	cursor   int
+	selected map[int]bool
+	section  YAMLPrompt
+}
+
+func newGenericMultiChoicePage(section YAMLPrompt) *genericMultiChoicePage {
+	return &genericMultiChoicePage{
+		selected: map[int]bool{},
+		section:  section,
+	}
+}
+
+func (g *genericMultiChoicePage) Title() string {
+	return idFromSection(g.section)
+}
+
+func (g *genericMultiChoicePage) Help() string {
+	return "↑/k: up • ↓/j: down • space: toggle • enter: submit"
+}
+
+func (g *genericMultiChoicePage) ID() string {
+	return idFromSection(g.section)
+}
+
+func (g *genericMultiChoicePage) BackTarget() string { return "customization" }
+
+func (g *genericMultiChoicePage) Init() tea.Cmd {
+	return nil
+}
+
+func (g *genericMultiChoicePage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if g.cursor > 0 {
+				g.cursor--
+			}
+		case "down", "j":
+			if g.cursor < len(g.section.Choices)-1 {
+				g.cursor++
+			}
+		case " ":
+			g.selected[g.cursor] = !g.selected[g.cursor]
+		case "enter":
+			// Keep the values in the order they are offered
+			values := []string{}
+			for i, choice := range g.section.Choices {
+				if g.selected[i] {
+					values = append(values, choice)
+				}
+			}
+			mainModel.log.Println("Setting value", values, "for section:", g.section.YAMLSection)
+			setValueForSectionInMainModel(values, g.section.YAMLSection)
+			return g, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
 		}
-		s += fmt.Sprintf("%s %s\n", cursor, option)
+	}
+	return g, nil
+}
+
+func (g *genericMultiChoicePage) View() string {
+	s := promptHeader(g.section) + "\n\n"
+
+	for i, choice := range g.section.Choices {
+		box := glyphs.Unchecked
+		if g.selected[i] {
+			box = lipgloss.NewStyle().Foreground(kairosAccent).Render(glyphs.Checked)
+		}
+		s += listItem(i, g.cursor, len(g.section.Choices), box+" "+choice) + "\n"
 	}
 
 	return s
@@ -153,14 +497,8 @@ func (g *genericBoolPage) View() string {
 // setValueForSectionInMainModel sets a value in the mainModel's extraFields map
 // for a given section, which is specified as a dot-separated string.
 // It creates nested maps as necessary to reach the specified section.
-func setValueForSectionInMainModel(value string, section string) {
+func setValueForSectionInMainModel(value any, section string) {
 	sections := strings.Split(section, ".")
-	// Transform "Yes" to "true" and "No" to "false"
-	if value == "Yes" {
-		value = "true"
-	} else {
-		value = "false"
-	}
 	// Ensure mainModel.extraFields is initialized
 	if mainModel.extraFields == nil {
 		mainModel.extraFields = make(map[string]interface{})
@@ -181,3 +519,43 @@ func setValueForSectionInMainModel(value string, section string) {
 		}
 	}
 }
+
+// valueForSectionInMainModel returns the value stored in the mainModel's extraFields map
+// for a given dot-separated section, and whether it is set at all.
+func valueForSectionInMainModel(section string) (any, bool) {
+	var current any = mainModel.extraFields
+	for _, key := range strings.Split(section, ".") {
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = currentMap[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// clearValueForSectionInMainModel removes the value for a given dot-separated section
+// from the mainModel's extraFields map, dropping any parent maps left empty.
+func clearValueForSectionInMainModel(section string) {
+	clearKeys(mainModel.extraFields, strings.Split(section, "."))
+}
+
+func clearKeys(currentMap map[string]interface{}, keys []string) {
+	if currentMap == nil || len(keys) == 0 {
+		return
+	}
+	if len(keys) == 1 {
+		delete(currentMap, keys[0])
+		return
+	}
+	nextMap, ok := currentMap[keys[0]].(map[string]interface{})
+	if !ok {
+		return
+	}
+	clearKeys(nextMap, keys[1:])
+	if len(nextMap) == 0 {
+		delete(currentMap, keys[0])
+	}
+}
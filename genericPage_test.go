@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+// Text answers used to be stored as "true" or "false", as if every prompt was a yes/no one
+func TestSetValueForSectionKeepsTextAnswers(t *testing.T) {
+	oldFields := mainModel.extraFields
+	t.Cleanup(func() { mainModel.extraFields = oldFields })
+	mainModel.extraFields = nil
+
+	values := map[string]any{
+		"k3s.node_name":    "worker-1",
+		"k3s.enabled":      true,
+		"p2p.network_id":   "No",
+		"options.replicas": 3,
+	}
+	for section, value := range values {
+		setValueForSectionInMainModel(value, section)
+	}
+	for section, want := range values {
+		if got, ok := valueForSectionInMainModel(section); !ok || got != want {
+			t.Errorf("%s = %#v, want %#v", section, got, want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// glyphSet holds the symbols used across the UI, so they can be swapped depending on what the terminal can render
+type glyphSet struct {
+	Check     string          // Completed items and configured options
+	Pending   string          // Configured options that still need checking, e.g. unresolved SSH key shorthands
+	Warning   string          // Destructive or risky actions
+	Success   string          // Installation finished
+	Filled    string          // Done portion of the progress bar
+	Empty     string          // Pending portion of the progress bar
+	Border    lipgloss.Border // Frame around the UI and popups
+	Spinner   spinner.Spinner // Shown while waiting on slow operations
+	Checked   string          // Selected entry in a multi-select list
+	Unchecked string          // Unselected entry in a multi-select list
+}
+
+var (
+	// unicodeGlyphs is the default for terminals with full unicode and emoji support
+	unicodeGlyphs = glyphSet{
+		Check:     "✓",
+		Pending:   "⧗",
+		Warning:   "⚠️",
+		Success:   "🎉",
+		Filled:    "█",
+		Empty:     "░",
+		Border:    lipgloss.RoundedBorder(),
+		Spinner:   spinner.Dot,
+		Checked:   "☑",
+		Unchecked: "☐",
+	}
+	// consoleGlyphs is for the linux console, which has box drawing characters but no emoji
+	consoleGlyphs = glyphSet{
+		Check:     "*",
+		Pending:   "~",
+		Warning:   "[!]",
+		Success:   "*",
+		Filled:    "█",
+		Empty:     "░",
+		Border:    lipgloss.NormalBorder(),
+		Spinner:   spinner.Line,
+		Checked:   "[x]",
+		Unchecked: "[ ]",
+	}
+	// asciiGlyphs is for serial consoles and dumb terminals
+	asciiGlyphs = glyphSet{
+		Check:     "[x]",
+		Pending:   "[~]",
+		Warning:   "[!]",
+		Success:   "[OK]",
+		Filled:    "#",
+		Empty:     ".",
+		Border:    lipgloss.ASCIIBorder(),
+		Spinner:   spinner.Line,
+		Checked:   "[x]",
+		Unchecked: "[ ]",
+	}
+)
+
+// glyphs is the glyph set in use, selected on startup based on TERM
+var glyphs = unicodeGlyphs
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// runHeadless installs with the answers in the given file, in the profile format, without any TUI.
+// The installer output is streamed to stdout, with the steps the TUI would show marked as they start.
+// The installer is stopped if it runs for longer than timeout, unless it is 0. Users without a password,
+// as in profiles saved without secrets, are refused unless allowEmptyPassword is set.
+func runHeadless(configPath string, timeout time.Duration, allowEmptyPassword bool) error {
+	mainModel = model{
+		log:         newLogger(),
+		extraFields: map[string]any{},
+	}
+	prof, err := loadProfile(configPath)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", configPath, err)
+	}
+	if prof.Disk == "" {
+		return errors.New("no disk set in " + configPath)
+	}
+	for _, u := range prof.Users {
+		if u.Password == "" && !allowEmptyPassword {
+			return fmt.Errorf("user %s has no password in %s, it may have been saved without secrets. Set one or pass --allow-empty-password", u.Name, configPath)
+		}
+	}
+	applyProfile(prof)
+	mainModel.disk = prof.Disk
+
+	cfg := NewInstallConfig(mainModel)
+	for _, v := range checkConfig(cfg) {
+		fmt.Fprintf(os.Stderr, "Warning: config check: %s\n", v)
+	}
+	if err := cfg.WriteYAML(installConfigPath()); err != nil {
+		return fmt.Errorf("%w: %w", ErrConfigWrite, err)
+	}
+	mainModel.log.Printf("Headless install to %s with %s", mainModel.disk, configPath)
+
+	begin := time.Now()
+	printStep := func(step string) {
+		fmt.Printf("==> %s\n", step)
+		notifyObservers(func(o InstallObserver) { o.OnStep(step) })
+	}
+	// Stop the install if it gets stuck, the same as the TUI does
+	var timedOut atomic.Bool
+	run := &installRun{}
+	if timeout > 0 {
+		timer := time.AfterFunc(timeout, func() {
+			timedOut.Store(true)
+			run.stop()
+		})
+		defer timer.Stop()
+	}
+	err = install(installConfigPath(), mainModel.disk, run, func(line string) {
+		fmt.Println(line)
+		notifyObservers(func(o InstallObserver) { o.OnOutput(line) })
+		if step, ok := stepFromLine(line); ok {
+			printStep(step)
+		}
+	}, printStep)
+	if err != nil && timedOut.Load() {
+		err = fmt.Errorf("%w after %s", ErrInstallTimeout, timeout)
+	}
+	recordOutcome(begin, err)
+	if err != nil {
+		notifyObservers(func(o InstallObserver) { o.OnError(err) })
+		return err
+	}
+	notifyObservers(func(o InstallObserver) { o.OnComplete() })
+	fmt.Printf("==> %s\n", InstallCompleteStep)
+	return nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHeadlessRefusesUserWithoutPassword(t *testing.T) {
+	oldModel, oldInstaller := mainModel, installer
+	t.Cleanup(func() { mainModel, installer = oldModel, oldInstaller })
+	installer = &fakeRunner{lines: fakeInstallerOutput} // Nothing real is run if the check lets it through
+
+	path := filepath.Join(t.TempDir(), "no-secrets.yaml")
+	prof := "disk: /dev/sda\nusers:\n  - name: kairos\n    password: kairos\n  - name: ops\n"
+	if err := os.WriteFile(path, []byte(prof), 0600); err != nil {
+		t.Fatal(err)
+	}
+	err := runHeadless(path, 0, false)
+	if err == nil || !strings.Contains(err.Error(), "user ops has no password") {
+		t.Errorf("headless install of a user without password gave %v", err)
+	}
+}
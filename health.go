@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// diskHealth is the SMART health of a disk
+type diskHealth string
+
+const (
+	healthUnknown diskHealth = "unknown" // No SMART data, smartctl missing or the disk doesn't support it
+	healthOK      diskHealth = "ok"
+	healthFailing diskHealth = "failing"
+)
+
+// smartTimeout bounds how long reading the SMART status of a disk can take, some USB bridges hang on it
+const smartTimeout = 5 * time.Second
+
+// readDiskHealth reads the SMART health of the given device with smartctl, if available.
+// This is best effort, anything that doesn't give a clear answer is reported as unknown.
+func readDiskHealth(device string) diskHealth {
+	if _, err := exec.LookPath("smartctl"); err != nil {
+		return healthUnknown
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), smartTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "smartctl", "-H", device).Output()
+	var exitErr *exec.ExitError
+	// Bit 3 of the exit status is set when the disk reports itself as failing
+	if errors.As(err, &exitErr) && exitErr.ExitCode()&8 != 0 {
+		return healthFailing
+	}
+	output := string(out)
+	switch {
+	case strings.Contains(output, "FAILED"):
+		return healthFailing
+	case strings.Contains(output, "PASSED"), strings.Contains(output, "SMART Health Status: OK"):
+		return healthOK
+	}
+	return healthUnknown
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+// inputHistory holds the values submitted to a text field during this session, so they can be recalled
+// with up/down, e.g. to fix a value that failed validation without typing it all over again
+type inputHistory struct {
+	entries []string
+	pos     int    // Index of the recalled entry, len(entries) while editing a new value
+	draft   string // What was being typed before recalling, restored when moving past the newest entry
+}
+
+// fieldHistory returns the history of the given field, creating it on first use
+func fieldHistory(field string) *inputHistory {
+	if mainModel.inputHistory == nil {
+		mainModel.inputHistory = map[string]*inputHistory{}
+	}
+	h, ok := mainModel.inputHistory[field]
+	if !ok {
+		h = &inputHistory{}
+		mainModel.inputHistory[field] = h
+	}
+	return h
+}
+
+// add records a submitted value, skipping empty values and repeats of the newest entry
+func (h *inputHistory) add(value string) {
+	if value != "" && (len(h.entries) == 0 || h.entries[len(h.entries)-1] != value) {
+		h.entries = append(h.entries, value)
+	}
+	h.pos = len(h.entries)
+	h.draft = ""
+}
+
+// recall moves through the history on up/down, loading the entry into the input.
+// It reports whether the key was handled, other keys are left to the input.
+func (h *inputHistory) recall(input *textinput.Model, key string) bool {
+	switch key {
+	case "up":
+		if h.pos == 0 {
+			return true
+		}
+		if h.pos == len(h.entries) {
+			h.draft = input.Value()
+		}
+		h.pos--
+		input.SetValue(h.entries[h.pos])
+	case "down":
+		if h.pos >= len(h.entries) {
+			return true
+		}
+		h.pos++
+		if h.pos == len(h.entries) {
+			input.SetValue(h.draft)
+		} else {
+			input.SetValue(h.entries[h.pos])
+		}
+	default:
+		return false
+	}
+	input.CursorEnd()
+	return true
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// hostnameSection is where the hostname is stored in extraFields
+const hostnameSection = "hostname"
+
+// defaultHostname generates a hostname unlikely to clash with other installs
+func defaultHostname() string {
+	b := make([]byte, 2)
+	if _, err := rand.Read(b); err != nil {
+		return "kairos"
+	}
+	return "kairos-" + hex.EncodeToString(b)
+}
+
+// Hostname Page
+type hostnamePage struct {
+	input textinput.Model
+	err   error
+}
+
+func newHostnamePage() *hostnamePage {
+	input := textinput.New()
+	input.Width = 40
+	input.CharLimit = 253
+	input.SetValue(mainModel.defaultHostname)
+	input.Focus()
+	return &hostnamePage{input: input}
+}
+
+func (p *hostnamePage) Init() tea.Cmd {
+	if value, ok := valueForSectionInMainModel(hostnameSection); ok {
+		p.input.SetValue(fmt.Sprintf("%v", value))
+	} else {
+		p.input.SetValue(mainModel.defaultHostname)
+	}
+	p.err = nil
+	return textinput.Blink
+}
+
+func (p *hostnamePage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			if p.err = validate(hostnameSection, p.input.Value()); p.err != nil {
+				mainModel.log.Printf("Invalid hostname %q: %v", p.input.Value(), p.err)
+				return p, nil
+			}
+			mainModel.log.Printf("Setting hostname to %s", p.input.Value())
+			setValueForSectionInMainModel(p.input.Value(), hostnameSection)
+			return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+		case "esc":
+			// Go back to customization page
+			return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+		}
+	}
+
+	p.input, cmd = p.input.Update(msg)
+	return p, cmd
+}
+
+func (p *hostnamePage) View() string {
+	s := "System Hostname\n\n"
+	s += "Hostname:\n"
+	s += p.input.View() + "\n\n"
+
+	if p.err != nil {
+		s += lipgloss.NewStyle().Foreground(kairosHighlight2).Render("Invalid hostname: "+p.err.Error()) + "\n"
+	}
+
+	return s
+}
+
+func (p *hostnamePage) Title() string {
+	return "Hostname"
+}
+
+func (p *hostnamePage) Help() string {
+	return "enter: save • esc: cancel"
+}
+
+func (p *hostnamePage) ID() string { return "hostname" }
+
+func (p *hostnamePage) BackTarget() string { return "customization" }
+
+func (p *hostnamePage) TakingTextInput() bool { return true }
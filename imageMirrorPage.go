@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// imageRef is an OCI image reference with an explicit registry: host[:port]/repository[:tag][@digest]
+var imageRef = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?)(:[0-9]{1,5})?(/[a-z0-9]+([._-][a-z0-9]+)*)+(:[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127})?(@sha256:[a-f0-9]{64})?$`)
+
+// validateImageMirror checks the image to install from is on a registry of its own, empty installs the
+// default image. Without a registry host the reference would point to Docker Hub, which is not reachable
+// on an air-gapped network anyway.
+func validateImageMirror(ref string) error {
+	if ref == "" {
+		return nil
+	}
+	if strings.Contains(ref, "://") {
+		return errors.New("give the image without a scheme, e.g. registry.local:5000/kairos/kairos:latest")
+	}
+	if !imageRef.MatchString(ref) {
+		return fmt.Errorf("%s is not an image reference like registry.local:5000/kairos/kairos:latest", ref)
+	}
+	host, _, _ := strings.Cut(ref, "/")
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return fmt.Errorf("%s has no registry, start it with the mirror host, e.g. registry.local/%s", ref, ref)
+	}
+	return nil
+}
+
+// Image Mirror Page, installs the OS image from a local registry for air-gapped installs
+type imageMirrorPage struct {
+	input textinput.Model
+	err   error
+}
+
+func newImageMirrorPage() *imageMirrorPage {
+	input := textinput.New()
+	input.Placeholder = "registry.local:5000/kairos/kairos:latest"
+	input.Width = 60
+	input.Focus()
+	return &imageMirrorPage{input: input}
+}
+
+func (p *imageMirrorPage) Init() tea.Cmd {
+	p.input.SetValue(mainModel.imageMirror)
+	p.err = nil
+	return textinput.Blink
+}
+
+func (p *imageMirrorPage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			value := strings.TrimPrefix(strings.TrimSpace(p.input.Value()), "oci:")
+			if p.err = validateImageMirror(value); p.err != nil {
+				mainModel.log.Printf("Invalid image mirror %q: %v", value, p.err)
+				return p, nil
+			}
+			mainModel.log.Printf("Setting image mirror to %q", value)
+			mainModel.imageMirror = value
+			return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+		case "esc":
+			// Go back to customization page
+			return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+		}
+	}
+
+	p.input, cmd = p.input.Update(msg)
+	return p, cmd
+}
+
+func (p *imageMirrorPage) View() string {
+	s := "Offline Image Mirror\n\n"
+	s += "OS image to install from a local registry, for networks without internet access:\n"
+	s += inputView(p.input) + "\n\n"
+	s += "Leave empty to install the image the installer ships with.\n"
+
+	if p.err != nil {
+		s += "\n" + lipgloss.NewStyle().Foreground(kairosHighlight2).Render("Invalid image: "+p.err.Error()) + "\n"
+	}
+
+	return s
+}
+
+func (p *imageMirrorPage) Title() string {
+	return "Offline Image Mirror"
+}
+
+func (p *imageMirrorPage) Help() string {
+	return "enter: save • esc: cancel"
+}
+
+func (p *imageMirrorPage) ID() string { return "image_mirror" }
+
+func (p *imageMirrorPage) BackTarget() string { return "customization" }
+
+func (p *imageMirrorPage) TakingTextInput() bool { return true }
@@ -1,11 +1,6 @@
 package main
 
-import (
-	"fmt"
-
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
-)
+import tea "github.com/charmbracelet/bubbletea"
 
 // Install Options Page
 type installOptionsPage struct {
@@ -57,16 +52,20 @@ func (p *installOptionsPage) View() string {
 	s += "Choose how to proceed:\n\n"
 
 	for i, option := range p.options {
-		cursor := " "
-		if p.cursor == i {
-			cursor = lipgloss.NewStyle().Foreground(kairosAccent).Render(">")
-		}
-		s += fmt.Sprintf("%s %s\n", cursor, option)
+		s += listItem(i, p.cursor, len(p.options), option) + "\n"
 	}
 
 	return s
 }
 
+// Details describes what the highlighted option leads to
+func (p *installOptionsPage) Details() string {
+	if p.cursor == 0 {
+		return "Review the answers given so far and start the installation. Anything not customized is left to the defaults of the image."
+	}
+	return "Set up users, SSH keys, the hostname and more before installing, including the options added by plugins."
+}
+
 func (p *installOptionsPage) Title() string {
 	return "Install Options"
 }
@@ -76,3 +75,15 @@ func (p *installOptionsPage) Help() string {
 }
 
 func (p *installOptionsPage) ID() string { return "install_options" }
+
+func (p *installOptionsPage) BackTarget() string {
+	if mainModel.skippedConfirm {
+		return "disk_selection"
+	}
+	return "confirmation"
+}
+
+// AutoAdvance starts the install without further customization
+func (p *installOptionsPage) AutoAdvance() tea.Cmd {
+	return func() tea.Msg { return GoToPageMsg{PageID: "summary"} }
+}
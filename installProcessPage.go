@@ -1,29 +1,50 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
-	"io"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // Install Process Page
 type installProcessPage struct {
+	noBackTarget
+
 	progress int
 	step     string
 	steps    []string
-	done     chan bool   // Channel to signal when installation is complete
-	output   chan string // Channel to receive output from the installer
-	cmd      *exec.Cmd   // Reference to the running installer command
+	weights  map[string]int // Relative duration of each step, for the progress percentage
+	done     chan bool      // Channel to signal when installation is complete
+	output   chan tea.Msg   // Channel to receive parsed output from the installer
+	quit     chan struct{}  // Closed when quitting, nothing reads the output anymore
+	run      *installRun    // Stops the installer or hook running
+	running  bool           // The install goroutine was started
+	aborted  bool           // The install was aborted
+	timedOut bool           // The install took longer than mainModel.installTimeout and was stopped
+	finished bool           // The outcome was recorded
+	started  time.Time      // When the install started, to record how long it took
+	err      error          // Why the install failed, if it did
+	logLines []string       // Latest lines of the installer output
+	logView  viewport.Model // Scrollable view of logLines
+	copied   string         // Outcome of copying the error details
 }
 
+// installLogHeight is how many lines of the installer output are shown
+const installLogHeight = 6
+
+// maxInstallLogLines is how many lines of the installer output are kept for scrolling back
+const maxInstallLogLines = 1000
+
+// installScrollKeys scroll the installer output. They are let through to the page during the install, when
+// every other key is held back so nothing interferes with the installer.
+var installScrollKeys = map[string]bool{"pgup": true, "pgdown": true, "home": true, "end": true}
+
 func newInstallProcessPage() *installProcessPage {
 	return &installProcessPage{
 		progress: 0,
@@ -39,170 +60,382 @@ func newInstallProcessPage() *installProcessPage {
 			InstallAfterInstallStep,
 			InstallCompleteStep,
 		},
-		done:   make(chan bool),
-		output: make(chan string),
+		weights: defaultStepWeights,
+		done:    make(chan bool),
+		output:  make(chan tea.Msg),
+		quit:    make(chan struct{}),
+		run:     &installRun{},
+		logView: viewport.New(0, installLogHeight),
+	}
+}
+
+// addHookSteps adds the steps for the hooks the branding provides, if any
+func (p *installProcessPage) addHookSteps() {
+	var steps []string
+	for _, step := range p.steps {
+		if step == InstallPreHooksStep || step == InstallPostHooksStep {
+			continue
+		}
+		if step == InstallCompleteStep && len(InstallHooks(PostInstallHooks)) > 0 {
+			steps = append(steps, InstallPostHooksStep)
+		}
+		steps = append(steps, step)
+		if step == InstallDefaultStep && len(InstallHooks(PreInstallHooks)) > 0 {
+			steps = append(steps, InstallPreHooksStep)
+		}
+	}
+	p.steps = steps
+}
+
+// addPluginSteps adds the steps the plugins declared, each after the step it asks for
+func (p *installProcessPage) addPluginSteps() {
+	if len(mainModel.pluginSteps) == 0 {
+		return
 	}
+	weights := make(map[string]int, len(p.weights))
+	for step, w := range p.weights {
+		weights[step] = w
+	}
+	for _, extra := range mainModel.pluginSteps {
+		if extra.Name == "" || slices.Contains(p.steps, extra.Name) {
+			continue
+		}
+		// Right before the install completes, unless the step it comes after is known
+		at := len(p.steps) - 1
+		if i := slices.Index(p.steps, extra.After); i >= 0 && i < len(p.steps)-1 {
+			at = i + 1
+		}
+		p.steps = slices.Insert(p.steps, at, extra.Name)
+		weights[extra.Name] = max(extra.Weight, 1)
+	}
+	p.weights = weights
+}
+
+// weight returns the relative duration of the given step
+func (p *installProcessPage) weight(step string) int {
+	if w, ok := p.weights[step]; ok {
+		return w
+	}
+	return 1
+}
+
+// progressPercent returns how much of the install is done, weighting each completed step by its duration
+func (p *installProcessPage) progressPercent() int {
+	if p.progress >= len(p.steps)-1 {
+		return 100
+	}
+	done, total := 0, 0
+	for i, step := range p.steps[:len(p.steps)-1] {
+		if i < p.progress {
+			done += p.weight(step)
+		}
+		total += p.weight(step)
+	}
+	if total == 0 {
+		return 0
+	}
+	return done * 100 / total
 }
 
 func (p *installProcessPage) Init() tea.Cmd {
+	p.started = time.Now()
+	p.addHookSteps()
+	p.addPluginSteps()
+	if err := verifyDisk(mainModel.disk, mainModel.selectedDisk); err != nil {
+		mainModel.log.Printf("Not installing: %v", err)
+		return func() tea.Msg { return InstallErrorMsg{Err: err} }
+	}
 	// Save the configuration before starting the installation
 	cfg := NewInstallConfig(mainModel)
-	_ = cfg.WriteYAML(filepath.Join(os.TempDir(), "kairos-install-config.yaml"))
+	for _, v := range checkConfig(cfg) {
+		mainModel.log.Printf("Config check: %s", v)
+	}
+	// There is nothing to resume once the install starts
+	removeSession()
+	if err := cfg.WriteYAML(installConfigPath()); err != nil {
+		mainModel.log.Printf("Error writing the install config: %v", err)
+		return func() tea.Msg { return InstallErrorMsg{Err: fmt.Errorf("%w: %w", ErrConfigWrite, err)} }
+	}
 	// Start the actual installer binary as a background process
+	device := mainModel.disk
+	p.running = true
 	go func() {
 		defer close(p.done)
 
-		cmd := exec.Command("kairos-agent", "manual-install", filepath.Join(os.TempDir(), "kairos-install-config.yaml"))
-		p.cmd = cmd // Store reference to cmd
-
-		// Create pipes for stdout and stderr
-		stdout, err := cmd.StdoutPipe()
+		err := install(installConfigPath(), device, p.run, func(line string) {
+			p.send(RawOutputMsg{Line: line})
+			if step, ok := stepFromLine(line); ok {
+				p.send(StepChangeMsg{Step: step})
+			}
+		}, func(step string) {
+			p.send(StepChangeMsg{Step: step})
+		})
 		if err != nil {
-			mainModel.log.Printf("Error creating stdout pipe: %v", err)
-			return
+			p.send(InstallErrorMsg{Err: err})
+		} else {
+			p.send(StepChangeMsg{Step: InstallCompleteStep})
 		}
+	}()
 
-		stderr, err := cmd.StderrPipe()
-		if err != nil {
-			mainModel.log.Printf("Error creating stderr pipe: %v", err)
-			return
-		}
-
-		// Start the command
-		if err := cmd.Start(); err != nil {
-			mainModel.log.Printf("Error starting installer: %v", err)
-			return
-		}
-
-		// Create a scanner to read stdout line by line
-		scanner := bufio.NewScanner(io.MultiReader(stdout, stderr))
-
-		// Read output and send it to the channel
-		go func() {
-			for scanner.Scan() {
-				line := scanner.Text()
-				mainModel.log.Printf("Installer output: %s", line)
-
-				// Parse output to determine current step based on keywords
-				// Basically the output of agent doesnt match exactly what we want to show in the UI,
-				// so we map what we found in the agent output to the steps we want to show in the UI.
-				if strings.Contains(line, AgentPartitionLog) {
-					p.output <- StepPrefix + InstallPartitionStep
-				} else if strings.Contains(line, AgentBeforeInstallLog) {
-					p.output <- StepPrefix + InstallBeforeInstallStep
-				} else if strings.Contains(line, AgentActiveLog) {
-					p.output <- StepPrefix + InstallActiveStep
-				} else if strings.Contains(line, AgentBootloaderLog) {
-					p.output <- StepPrefix + InstallBootloaderStep
-				} else if strings.Contains(line, AgentRecoveryLog) {
-					p.output <- StepPrefix + InstallRecoveryStep
-				} else if strings.Contains(line, AgentPassiveLog) {
-					p.output <- StepPrefix + InstallPassiveStep
-				} else if strings.Contains(line, AgentAfterInstallLog) && !strings.Contains(line, "chroot") {
-					p.output <- StepPrefix + InstallAfterInstallStep
-				} else if strings.Contains(line, AgentCompleteLog) {
-					p.output <- StepPrefix + InstallCompleteStep
-				}
-			}
-		}()
+	// Start reading the installer output
+	if mainModel.installTimeout > 0 {
+		return tea.Batch(p.waitForOutput(), tea.Tick(mainModel.installTimeout, func(time.Time) tea.Msg { return installTimeoutMsg{} }))
+	}
+	return p.waitForOutput()
+}
 
-		// Wait for the command to complete
-		if err := cmd.Wait(); err != nil {
-			mainModel.log.Printf("Error waiting for installer: %v", err)
-			p.output <- ErrorPrefix + err.Error()
-		} else {
-			mainModel.log.Printf("Installation completed successfully")
-			p.output <- StepPrefix + InstallCompleteStep
+// send hands a message to the UI loop, unless it is quitting and won't read it anymore
+func (p *installProcessPage) send(msg tea.Msg) {
+	select {
+	case p.output <- msg:
+	case <-p.quit:
+	}
+}
+
+// stepFromLine maps a line of the installer output to the step it starts, if any.
+// Basically the output of agent doesnt match exactly what we want to show in the UI,
+// so we map what we found in the agent output to the steps we want to show in the UI.
+func stepFromLine(line string) (string, bool) {
+	for _, extra := range mainModel.pluginSteps {
+		if extra.Log != "" && strings.Contains(line, extra.Log) {
+			return extra.Name, true
 		}
-	}()
+	}
+	switch {
+	case strings.Contains(line, AgentPartitionLog):
+		return InstallPartitionStep, true
+	case strings.Contains(line, AgentBeforeInstallLog):
+		return InstallBeforeInstallStep, true
+	case strings.Contains(line, AgentActiveLog):
+		return InstallActiveStep, true
+	case strings.Contains(line, AgentBootloaderLog):
+		return InstallBootloaderStep, true
+	case strings.Contains(line, AgentRecoveryLog):
+		return InstallRecoveryStep, true
+	case strings.Contains(line, AgentPassiveLog):
+		return InstallPassiveStep, true
+	case strings.Contains(line, AgentAfterInstallLog) && !strings.Contains(line, "chroot"):
+		return InstallAfterInstallStep, true
+	case strings.Contains(line, AgentCompleteLog):
+		return InstallCompleteStep, true
+	}
+	return "", false
+}
+
+// StepChangeMsg is sent when the installer moves on to a new step
+type StepChangeMsg struct {
+	Step string
+}
+
+// RawOutputMsg carries a line of the installer output as is
+type RawOutputMsg struct {
+	Line string
+}
 
-	// Return a command that will check for output from the installer
+// InstallErrorMsg is sent when the installer fails
+type InstallErrorMsg struct {
+	Err error
+}
+
+// installTimeoutMsg is sent once the install has been running for mainModel.installTimeout
+type installTimeoutMsg struct{}
+
+// InstallDoneMsg is sent once the installer goroutine is finished
+type InstallDoneMsg struct{}
+
+// installStoppedMsg is sent once an install aborted to quit has exited
+type installStoppedMsg struct{}
+
+// waitForOutput returns a command that blocks until the installer reports something, returning it as
+// a message. Update issues it again after each message so the whole output is streamed without polling.
+func (p *installProcessPage) waitForOutput() tea.Cmd {
 	return func() tea.Msg {
-		return CheckInstallerMsg{}
+		select {
+		case msg := <-p.output:
+			return msg
+		case <-p.done:
+			return InstallDoneMsg{}
+		}
 	}
 }
 
-// CheckInstallerMsg Message type to check for installer output
-type CheckInstallerMsg struct{}
-
 func (p *installProcessPage) Update(msg tea.Msg) (Page, tea.Cmd) {
-	switch msg.(type) {
-	case CheckInstallerMsg:
-		// Check for new output from the installer
-		select {
-		case output, ok := <-p.output:
-			if !ok {
-				// Channel closed, installer is done
-				return p, nil
+	switch msg := msg.(type) {
+	case StepChangeMsg:
+		// Find the index of the step
+		for i, s := range p.steps {
+			if s == msg.Step {
+				p.progress = i
+				p.step = msg.Step
+				break
 			}
+		}
+		notifyObservers(func(o InstallObserver) { o.OnStep(msg.Step) })
+		// Continue reading the output
+		return p, p.waitForOutput()
+
+	case installTimeoutMsg:
+		if p.progress < len(p.steps)-1 && p.err == nil && !p.aborted {
+			mainModel.log.Printf("Install still running after %s, stopping it", mainModel.installTimeout)
+			p.timedOut = true
+			p.run.stop()
+		}
+		return p, nil
 
-			// Process the output
-			if strings.HasPrefix(output, StepPrefix) {
-				// This is a step change notification
-				stepName := strings.TrimPrefix(output, StepPrefix)
-
-				// Find the index of the step
-				for i, s := range p.steps {
-					if s == stepName {
-						p.progress = i
-						p.step = stepName
-						break
-					}
-				}
-			} else if strings.HasPrefix(output, ErrorPrefix) {
-				// Handle error
-				errorMsg := strings.TrimPrefix(output, ErrorPrefix)
-				p.step = "Error: " + errorMsg
-				return p, nil
-			}
+	case installStoppedMsg:
+		// The error of the killed install may not have been read, quitting
+		if !p.finished {
+			p.err = ErrAborted
+			p.finish(ErrAborted)
+		}
+		return p, tea.Quit
 
-			// Continue checking for output
-			return p, func() tea.Msg { return CheckInstallerMsg{} }
+	case RawOutputMsg:
+		notifyObservers(func(o InstallObserver) { o.OnOutput(msg.Line) })
+		p.logLines = append(p.logLines, msg.Line)
+		if len(p.logLines) > maxInstallLogLines {
+			p.logLines = p.logLines[len(p.logLines)-maxInstallLogLines:]
+		}
+		p.refreshLog()
+		return p, p.waitForOutput()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "pgup":
+			p.logView.PageUp()
+		case "pgdown":
+			p.logView.PageDown()
+		case "home":
+			p.logView.GotoTop()
+		case "end":
+			p.logView.GotoBottom()
+		case "c":
+			if p.err != nil {
+				p.copied = copyErrorDetails(p.err, p.logLines)
+			}
+		}
+		return p, nil
 
-		case <-p.done:
-			// Installer is finished
-			p.progress = len(p.steps) - 1
-			p.step = p.steps[len(p.steps)-1]
+	case InstallErrorMsg:
+		if p.finished {
 			return p, nil
-
-		default:
-			// No new output yet, check again after a short delay
-			return p, tea.Tick(time.Millisecond*100, func(_ time.Time) tea.Msg {
-				return CheckInstallerMsg{}
-			})
 		}
+		// The installer may only have failed because it was killed
+		if p.aborted {
+			msg.Err = ErrAborted
+		} else if p.timedOut {
+			msg.Err = fmt.Errorf("%w after %s", ErrInstallTimeout, mainModel.installTimeout)
+		}
+		p.step = "Error: " + msg.Err.Error()
+		p.err = msg.Err
+		p.finish(msg.Err)
+		notifyObservers(func(o InstallObserver) { o.OnError(msg.Err) })
+		return p, nil
+
+	case InstallDoneMsg:
+		if p.finished || p.aborted {
+			return p, nil // Reported already, or by installStoppedMsg
+		}
+		// Installer is finished
+		p.progress = len(p.steps) - 1
+		p.step = p.steps[len(p.steps)-1]
+		p.finish(nil)
+		notifyObservers(func(o InstallObserver) { o.OnComplete() })
+		return p, nil
 	}
 
 	return p, nil
 }
 
+// refreshLog updates the output view with the latest lines, following the output unless scrolled back
+func (p *installProcessPage) refreshLog() {
+	follow := p.logView.AtBottom()
+	p.logView.Width = contentWidth()
+	lines := make([]string, len(p.logLines))
+	for i, line := range p.logLines {
+		// Long lines are cut instead of wrapped, so the view keeps its height
+		lines[i] = ansi.Truncate(line, p.logView.Width, "…")
+	}
+	p.logView.SetContent(strings.Join(lines, "\n"))
+	if follow {
+		p.logView.GotoBottom()
+	}
+}
+
+// finish records the outcome of the install for monitoring and auditing
+func (p *installProcessPage) finish(installErr error) {
+	p.finished = true
+	switch {
+	case p.aborted && mainModel.outcome == outcomeSignaled:
+		// Reported as stopped by the signal
+	case p.aborted:
+		mainModel.outcome = outcomeAborted
+	case installErr != nil:
+		mainModel.outcome = outcomeFailed
+	default:
+		mainModel.outcome = outcomeSucceeded
+	}
+	recordOutcome(p.started, installErr)
+}
+
+// installViewLines is how many lines of the install view are not the completed steps
+const installViewLines = 10
+
+// barColor is the color of the progress bar, telling at a glance how the install is going
+func (p *installProcessPage) barColor() lipgloss.Color {
+	switch {
+	case p.err != nil || p.aborted:
+		return kairosFailure
+	case p.progress >= len(p.steps)-1:
+		return kairosSuccess
+	}
+	return kairosHighlight2
+}
+
 func (p *installProcessPage) View() string {
 	s := "Installation in Progress\n\n"
 
 	// Progress bar
-	totalSteps := len(p.steps)
-	progressPercent := (p.progress * 100) / (totalSteps - 1)
+	progressPercent := p.progressPercent()
 	barWidth := 40 // Make progress bar wider
 	filled := barWidth * progressPercent / 100
-	progressBar := lipgloss.NewStyle().Foreground(kairosHighlight2).Background(kairosBg).Render(strings.Repeat("█", filled)) +
-		lipgloss.NewStyle().Foreground(kairosBorder).Background(kairosBg).Render(strings.Repeat("░", barWidth-filled))
+	progressBar := lipgloss.NewStyle().Foreground(p.barColor()).Background(kairosBg).Render(strings.Repeat(glyphs.Filled, filled)) +
+		lipgloss.NewStyle().Foreground(kairosBorder).Background(kairosBg).Render(strings.Repeat(glyphs.Empty, barWidth-filled))
 
 	s += "Progress:" + progressBar + lipgloss.NewStyle().Background(kairosBg).Render(" ")
 	s += lipgloss.NewStyle().Foreground(kairosText).Background(kairosBg).Bold(true).Render(fmt.Sprintf("%d%%", progressPercent))
 	s += "\n\n"
 	s += fmt.Sprintf("Current step: %s\n\n", p.step)
+	if hint := errorGuidance(p.err); hint != "" {
+		s += lipgloss.NewStyle().Foreground(kairosHighlight2).Render(hint) + "\n\n"
+	}
+	if p.copied != "" {
+		s += p.copied + "\n\n"
+	}
 
-	// Show completed steps
+	// Show completed steps, only the latest ones if they don't all fit
 	s += "Completed steps:\n"
-	tick := lipgloss.NewStyle().Foreground(kairosAccent).Render(checkMark)
-	for i := 0; i < p.progress; i++ {
+	tick := lipgloss.NewStyle().Foreground(kairosAccent).Render(glyphs.Check)
+	first := 0
+	if shown := max(contentHeight()-installViewLines-installLogHeight-2, 1); mainModel.height > 0 && p.progress > shown {
+		first = p.progress - shown + 1
+		s += lipgloss.NewStyle().Faint(true).Render(fmt.Sprintf("...and %d earlier steps", first)) + "\n"
+	}
+	for i := first; i < p.progress; i++ {
 		s += fmt.Sprintf("%s %s\n", tick, p.steps[i])
 	}
 
-	if p.progress < len(p.steps)-1 {
-		s += "\n[!]  Do not power off the system during installation!"
-	} else {
-		s += "\nInstallation completed successfully!"
+	if len(p.logLines) > 0 {
+		s += "\nInstaller output:\n" + lipgloss.NewStyle().Faint(true).Render(p.logView.View()) + "\n"
+	}
+
+	switch {
+	case p.err != nil:
+		s += fmt.Sprintf("\n%s  Installation failed, nothing is running anymore. Install again before booting from the disk.", glyphs.Warning)
+	case p.progress < len(p.steps)-1:
+		s += fmt.Sprintf("\n%s  Do not power off the system during installation!", glyphs.Warning)
+	default:
+		s += fmt.Sprintf("\n%s Installation completed successfully!", glyphs.Success)
 		s += "\nYou can now reboot your system."
 	}
 
@@ -215,29 +448,48 @@ func (p *installProcessPage) Title() string {
 
 func (p *installProcessPage) Help() string {
 	if p.progress >= len(p.steps)-1 {
-		return "Press any key to exit"
+		return "pgup/pgdown: scroll output • Press any other key to exit"
+	}
+	if p.err != nil {
+		return "c: copy error details • pgup/pgdown: scroll output • q/ctrl+c: quit"
 	}
-	return "Installation in progress - Use ctrl+c to abort"
+	return "Installation in progress - pgup/pgdown: scroll output • ctrl+c: abort"
 }
 
 func (p *installProcessPage) ID() string { return "install_process" }
 
-// Abort aborts the running installer process and cleans up
+// Abort kills the installer or hook running and keeps the install from going on. The installer goroutine
+// notices it exiting and cleans up.
 func (p *installProcessPage) Abort() {
-	if p.cmd != nil && p.cmd.Process != nil {
-		_ = p.cmd.Process.Kill()
-		mainModel.log.Printf("Installer process aborted by user")
+	p.aborted = true
+	mainModel.outcome = outcomeAborted
+	p.run.stop()
+}
+
+// installStopTimeout is how long quitting waits for a killed install to exit
+const installStopTimeout = 10 * time.Second
+
+// abortAndQuit aborts the install and quits once whatever it was running has been killed and waited for,
+// so nothing is left running behind the installer
+func (p *installProcessPage) abortAndQuit() tea.Cmd {
+	if p.finished {
+		return tea.Quit
 	}
-	// Close output channel if not already closed
+	p.Abort()
 	select {
-	case <-p.done:
-		// already closed
+	case <-p.quit:
 	default:
-		close(p.done)
+		close(p.quit)
 	}
-	// Optionally, send a message to output channel
-	select {
-	case p.output <- ErrorPrefix + "Installation aborted by user":
-	default:
+	if !p.running {
+		return tea.Quit
+	}
+	return func() tea.Msg {
+		select {
+		case <-p.done:
+		case <-time.After(installStopTimeout):
+			mainModel.log.Printf("Install still running %s after being killed, quitting anyway", installStopTimeout)
+		}
+		return installStoppedMsg{}
 	}
 }
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// setupInstall points everything the install touches at a temporary directory and makes runner the
+// installer, returning a fresh install page
+func setupInstall(t *testing.T, runner installerRunner) *installProcessPage {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+	disk := filepath.Join(dir, "disk")
+	if err := os.WriteFile(disk, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	oldModel, oldInstaller, oldBranding := mainModel, installer, brandingDir
+	oldAttempts, oldMarker, oldSession := attemptLogPath, completionMarkerPath, sessionPath
+	t.Cleanup(func() {
+		mainModel, installer, brandingDir = oldModel, oldInstaller, oldBranding
+		attemptLogPath, completionMarkerPath, sessionPath = oldAttempts, oldMarker, oldSession
+	})
+
+	p := newInstallProcessPage()
+	mainModel = model{log: log.New(io.Discard, "", 0), disk: disk, pages: []Page{p}, currentPageID: p.ID()}
+	installer = runner
+	brandingDir = func() string { return "" }
+	attemptLogPath = filepath.Join(dir, "attempts.jsonl")
+	completionMarkerPath = filepath.Join(dir, "install-complete")
+	sessionPath = filepath.Join(dir, "session.json")
+	return p
+}
+
+// runInstall plays the part of the Bubble Tea runtime for the install page: it runs the commands the page
+// returns, feeds their messages back to it and returns them in order once the install is over, or once
+// quitting. onMsg is called after each message is handled, to act on the page mid-install, the command it
+// returns is run too.
+func runInstall(t *testing.T, p *installProcessPage, onMsg func(tea.Msg) tea.Cmd) []tea.Msg {
+	t.Helper()
+	msgs := make(chan tea.Msg)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	var run func(cmd tea.Cmd)
+	run = func(cmd tea.Cmd) {
+		if cmd == nil {
+			return
+		}
+		go func() {
+			msg := cmd()
+			if batch, ok := msg.(tea.BatchMsg); ok {
+				for _, cmd := range batch {
+					run(cmd)
+				}
+				return
+			}
+			if msg == nil {
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-stop:
+			}
+		}()
+	}
+
+	run(p.Init())
+	var seen []tea.Msg
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case msg := <-msgs:
+			seen = append(seen, msg)
+			if _, ok := msg.(tea.QuitMsg); ok {
+				return seen
+			}
+			_, cmd := p.Update(msg)
+			if onMsg != nil {
+				cmd = tea.Batch(cmd, onMsg(msg))
+			}
+			if p.finished && !quitting(p) {
+				return seen
+			}
+			run(cmd)
+		case <-timeout:
+			t.Fatalf("install still running, got %d messages", len(seen))
+		}
+	}
+}
+
+// quitting reports whether the page is waiting for the install to stop to quit
+func quitting(p *installProcessPage) bool {
+	select {
+	case <-p.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// stepsSeen returns the steps the messages moved the page to, in order
+func stepsSeen(msgs []tea.Msg) []string {
+	var steps []string
+	for _, msg := range msgs {
+		if change, ok := msg.(StepChangeMsg); ok {
+			steps = append(steps, change.Step)
+		}
+	}
+	return slices.Compact(steps)
+}
+
+func TestStepFromLine(t *testing.T) {
+	tests := []struct {
+		line string
+		step string
+		ok   bool
+	}{
+		{line: AgentPartitionLog + " /dev/sda", step: InstallPartitionStep, ok: true},
+		{line: "INFO " + AgentBeforeInstallLog, step: InstallBeforeInstallStep, ok: true},
+		{line: AgentActiveLog + " /run/cos/state/cOS/active.img", step: InstallActiveStep, ok: true},
+		{line: AgentBootloaderLog, step: InstallBootloaderStep, ok: true},
+		{line: AgentRecoveryLog, step: InstallRecoveryStep, ok: true},
+		{line: AgentPassiveLog, step: InstallPassiveStep, ok: true},
+		{line: AgentAfterInstallLog, step: InstallAfterInstallStep, ok: true},
+		{line: AgentAfterInstallLog + " chroot", ok: false},
+		{line: AgentCompleteLog, step: InstallCompleteStep, ok: true},
+		{line: "Copying files", ok: false},
+		{line: "", ok: false},
+	}
+	for _, tt := range tests {
+		step, ok := stepFromLine(tt.line)
+		if step != tt.step || ok != tt.ok {
+			t.Errorf("stepFromLine(%q) = %q, %v, want %q, %v", tt.line, step, ok, tt.step, tt.ok)
+		}
+	}
+}
+
+func TestStepFromLinePluginSteps(t *testing.T) {
+	oldSteps := mainModel.pluginSteps
+	t.Cleanup(func() { mainModel.pluginSteps = oldSteps })
+	mainModel.pluginSteps = []InstallStep{{Name: "Enrolling", Log: "enrolling node"}}
+	if step, ok := stepFromLine("plugin: enrolling node 3"); !ok || step != "Enrolling" {
+		t.Errorf("plugin step line mapped to %q, %v", step, ok)
+	}
+}
+
+func TestInstallProgress(t *testing.T) {
+	p := setupInstall(t, &fakeRunner{lines: fakeInstallerOutput})
+	last := 0
+	msgs := runInstall(t, p, func(tea.Msg) tea.Cmd {
+		if p.progress < last {
+			t.Errorf("progress went back from %d to %d", last, p.progress)
+		}
+		last = p.progress
+		return nil
+	})
+
+	if got, want := stepsSeen(msgs), p.steps[1:]; !slices.Equal(got, want) {
+		t.Errorf("went through steps %q, want %q", got, want)
+	}
+	if _, ok := msgs[len(msgs)-1].(InstallDoneMsg); !ok {
+		t.Errorf("install ended with %#v, want InstallDoneMsg", msgs[len(msgs)-1])
+	}
+	if p.err != nil {
+		t.Errorf("install failed: %v", p.err)
+	}
+	if p.progress != len(p.steps)-1 || p.step != InstallCompleteStep || p.progressPercent() != 100 {
+		t.Errorf("install ended at step %d %q, %d%%", p.progress, p.step, p.progressPercent())
+	}
+	if !slices.Equal(p.logLines, fakeInstallerOutput) {
+		t.Errorf("logged %q, want the installer output", p.logLines)
+	}
+	if mainModel.outcome != outcomeSucceeded {
+		t.Errorf("outcome %v, want succeeded", mainModel.outcome)
+	}
+}
+
+func TestInstallError(t *testing.T) {
+	p := setupInstall(t, &fakeRunner{lines: fakeInstallerOutput[:4], err: errors.New("exit status 1")})
+	msgs := runInstall(t, p, nil)
+
+	if !errors.Is(p.err, ErrInstallerExit) {
+		t.Fatalf("install failed with %v, want %v", p.err, ErrInstallerExit)
+	}
+	if _, ok := msgs[len(msgs)-1].(InstallErrorMsg); !ok {
+		t.Errorf("install ended with %#v, want InstallErrorMsg", msgs[len(msgs)-1])
+	}
+	// Stuck at the last step the installer got to
+	if got := p.steps[p.progress]; got != InstallActiveStep {
+		t.Errorf("stopped at step %q, want %q", got, InstallActiveStep)
+	}
+	if !strings.HasPrefix(p.step, "Error: ") {
+		t.Errorf("current step %q doesn't tell about the error", p.step)
+	}
+	if p.progressPercent() == 100 {
+		t.Errorf("failed install shown as complete")
+	}
+	if mainModel.outcome != outcomeFailed {
+		t.Errorf("outcome %v, want failed", mainModel.outcome)
+	}
+	if strings.Contains(p.View(), "Do not power off") {
+		t.Errorf("failed install still warns about powering off")
+	}
+	// Nothing left to abort, ctrl+c quits straight away
+	_, cmd := mainModel.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if mainModel.showAbortConfirm || cmd == nil || cmd() != tea.Quit() {
+		t.Errorf("ctrl+c after the install failed asks to abort: %v", mainModel.showAbortConfirm)
+	}
+}
+
+func TestInstallDiskGone(t *testing.T) {
+	p := setupInstall(t, &fakeRunner{lines: fakeInstallerOutput})
+	mainModel.disk = filepath.Join(t.TempDir(), "missing")
+	msgs := runInstall(t, p, nil)
+
+	if !errors.Is(p.err, ErrDiskChanged) {
+		t.Errorf("install failed with %v, want %v", p.err, ErrDiskChanged)
+	}
+	if steps := stepsSeen(msgs); len(steps) > 0 {
+		t.Errorf("installer ran steps %q on a missing disk", steps)
+	}
+}
+
+// addHook installs an executable hook script for the given stage in a branding directory made for the test
+func addHook(t *testing.T, stage, script string) {
+	t.Helper()
+	dir := brandingDir()
+	if dir == "" {
+		dir = t.TempDir()
+		brandingDir = func() string { return dir }
+	}
+	hooks := filepath.Join(dir, stage+".d")
+	if err := os.MkdirAll(hooks, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hooks, "10-test"), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The tests below are meant to be run with -race as well, they check the install goroutine keeps off
+// the state the Update loop changes
+
+func TestInstallAbort(t *testing.T) {
+	p := setupInstall(t, &fakeRunner{lines: fakeInstallerOutput, delay: 20 * time.Millisecond})
+	runInstall(t, p, func(msg tea.Msg) tea.Cmd {
+		if _, ok := msg.(RawOutputMsg); ok && !p.aborted {
+			p.Abort()
+		}
+		return nil
+	})
+
+	if !errors.Is(p.err, ErrAborted) {
+		t.Errorf("install ended with %v, want %v", p.err, ErrAborted)
+	}
+	if p.progress >= len(p.steps)-1 {
+		t.Errorf("aborted install shown as complete")
+	}
+	if mainModel.outcome != outcomeAborted {
+		t.Errorf("outcome %v, want aborted", mainModel.outcome)
+	}
+}
+
+func TestInstallHooksGetDevice(t *testing.T) {
+	p := setupInstall(t, &fakeRunner{lines: fakeInstallerOutput})
+	addHook(t, PreInstallHooks, `echo "pre $KAIROS_INSTALL_DEVICE"`)
+	addHook(t, PostInstallHooks, `echo "post $KAIROS_INSTALL_DEVICE"`)
+	device := mainModel.disk
+	msgs := runInstall(t, p, func(tea.Msg) tea.Cmd {
+		// Changed by the Update loop while the install runs, the hooks still get the device it started with
+		mainModel.disk = "/dev/changed"
+		return nil
+	})
+
+	if p.err != nil {
+		t.Fatalf("install failed: %v", p.err)
+	}
+	for _, want := range []string{"pre " + device, "post " + device} {
+		if !slices.Contains(p.logLines, want) {
+			t.Errorf("hook output %q missing from %q", want, p.logLines)
+		}
+	}
+	steps := stepsSeen(msgs)
+	if steps[0] != InstallPreHooksStep || steps[len(steps)-2] != InstallPostHooksStep {
+		t.Errorf("went through steps %q, want the hook steps first and before completing", steps)
+	}
+}
+
+func TestInstallTimeoutDuringPreHook(t *testing.T) {
+	p := setupInstall(t, &fakeRunner{lines: fakeInstallerOutput})
+	addHook(t, PreInstallHooks, "sleep 30")
+	mainModel.installTimeout = 200 * time.Millisecond
+	begin := time.Now()
+	runInstall(t, p, nil)
+
+	if !errors.Is(p.err, ErrInstallTimeout) {
+		t.Errorf("install ended with %v, want %v", p.err, ErrInstallTimeout)
+	}
+	if took := time.Since(begin); took > 5*time.Second {
+		t.Errorf("install took %s, the hook wasn't killed", took)
+	}
+	if slices.Contains(p.logLines, fakeInstallerOutput[0]) {
+		t.Errorf("installer started after timing out")
+	}
+	if mainModel.outcome != outcomeFailed {
+		t.Errorf("outcome %v, want failed", mainModel.outcome)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultInstallTimeout is how long the installer can run before it is considered stuck. Slow disks and
+// big images can take a while, so this is generous.
+const defaultInstallTimeout = 30 * time.Minute
+
+// installConfigPath is where the generated config is written for the installer to pick up
+func installConfigPath() string {
+	return filepath.Join(os.TempDir(), "kairos-install-config.yaml")
+}
+
+// installerProcess is a running installer
+type installerProcess interface {
+	Output() io.Reader // Everything the installer prints
+	Wait() error       // Waits for the installer to exit, after its output has been read
+	Kill() error
+}
+
+// installerRunner starts the installer. It is an interface so the real installer can be swapped for a fake
+// one, to exercise the progress handling without a disk to install to.
+type installerRunner interface {
+	Start(configPath string) (installerProcess, error)
+}
+
+// installer is the runner used to install
+var installer installerRunner = agentRunner{}
+
+// agentRunner runs kairos-agent
+type agentRunner struct{}
+
+// agentProcess is a running kairos-agent
+type agentProcess struct {
+	cmd    *exec.Cmd
+	output io.Reader
+}
+
+func (agentRunner) Start(configPath string) (installerProcess, error) {
+	cmd := exec.Command("kairos-agent", "manual-install", configPath)
+	inOwnGroup(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating stderr pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &agentProcess{cmd: cmd, output: io.MultiReader(stdout, stderr)}, nil
+}
+
+func (a *agentProcess) Output() io.Reader { return a.output }
+
+func (a *agentProcess) Wait() error { return a.cmd.Wait() }
+
+func (a *agentProcess) Kill() error { return killGroup(a.cmd) }
+
+// inOwnGroup makes cmd start in a process group of its own, so killGroup kills whatever it starts along with it
+func inOwnGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killGroup kills the process group of a command started inOwnGroup
+func killGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// installRun lets an install be stopped from outside of the goroutine running it. Whatever the install runs
+// at the time, the installer or a hook, is killed, and nothing else is started afterwards.
+type installRun struct {
+	mu      sync.Mutex
+	stopped bool
+	kill    func() error // Kills the running process, nil between processes
+}
+
+// running records how to kill the process just started. If the install was stopped meanwhile the
+// process is killed right away, it still has to be waited for.
+func (r *installRun) running(kill func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.kill = kill
+	if r.stopped {
+		_ = kill()
+	}
+}
+
+// exited forgets the process once it has been waited for
+func (r *installRun) exited() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.kill = nil
+}
+
+// stop kills the running process, if any, and keeps the install from starting anything else
+func (r *installRun) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = true
+	if r.kill != nil {
+		_ = r.kill()
+		mainModel.log.Printf("Install process killed")
+	}
+}
+
+// isStopped reports whether the install was stopped
+func (r *installRun) isStopped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stopped
+}
+
+// runInstaller runs the installer on the given config, calling onLine for every line it outputs, and
+// waits for it to finish. Stopping run kills it.
+func runInstaller(configPath string, run *installRun, onLine func(string)) error {
+	if run.isStopped() {
+		return ErrAborted
+	}
+	proc, err := installer.Start(configPath)
+	if err != nil {
+		mainModel.log.Printf("Error starting installer: %v", err)
+		return fmt.Errorf("%w: %w", ErrInstallerStart, err)
+	}
+	run.running(proc.Kill)
+	defer run.exited()
+
+	// The output has to be read completely before waiting, Wait closes the pipes
+	scanner := bufio.NewScanner(proc.Output())
+	for scanner.Scan() {
+		line := scanner.Text()
+		mainModel.log.Printf("Installer output: %s", line)
+		onLine(line)
+	}
+
+	if err := proc.Wait(); err != nil {
+		mainModel.log.Printf("Error waiting for installer: %v", err)
+		return fmt.Errorf("%w: %w", ErrInstallerExit, err)
+	}
+	mainModel.log.Printf("Installation completed successfully")
+	return nil
+}
+
+// install runs the pre-install hooks, the installer and the post-install hooks in turn. onLine gets the output
+// of all of them and onStep is told when the hooks start, the installer steps are to be found in its output.
+// A failing pre-install hook stops the install, a failing post-install hook is only reported.
+// It runs outside of the Update loop, so it is given the device instead of reading it from mainModel.
+// Stopping run kills whatever is running and returns once it exited.
+func install(configPath, device string, run *installRun, onLine, onStep func(string)) error {
+	if hooks := InstallHooks(PreInstallHooks); len(hooks) > 0 {
+		onStep(InstallPreHooksStep)
+		if err := runHooks(hooks, configPath, device, run, onLine); err != nil {
+			return fmt.Errorf("%w: %w", ErrPreHook, err)
+		}
+	}
+	if err := runInstaller(configPath, run, onLine); err != nil {
+		return err
+	}
+	if hooks := InstallHooks(PostInstallHooks); len(hooks) > 0 {
+		onStep(InstallPostHooksStep)
+		if err := runHooks(hooks, configPath, device, run, onLine); err != nil {
+			mainModel.log.Printf("Post-install hook failed: %v", err)
+			onLine(fmt.Sprintf("Warning: post-install hook failed: %v", err))
+		}
+	}
+	return nil
+}
+
+// runHooks runs the given hook scripts one after the other, stopping at the first one failing.
+// The hooks get the generated config and the target device in KAIROS_INSTALL_CONFIG and KAIROS_INSTALL_DEVICE.
+func runHooks(hooks []string, configPath, device string, run *installRun, onLine func(string)) error {
+	for _, hook := range hooks {
+		if run.isStopped() {
+			return ErrAborted
+		}
+		mainModel.log.Printf("Running hook %s", hook)
+		cmd := exec.Command(hook)
+		cmd.Env = append(os.Environ(), "KAIROS_INSTALL_CONFIG="+configPath, "KAIROS_INSTALL_DEVICE="+device)
+		out, err := cmd.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		cmd.Stderr = cmd.Stdout
+		inOwnGroup(cmd)
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("%s: %w", hook, err)
+		}
+		run.running(func() error { return killGroup(cmd) })
+		scanner := bufio.NewScanner(out)
+		for scanner.Scan() {
+			mainModel.log.Printf("Hook output: %s", scanner.Text())
+			onLine(scanner.Text())
+		}
+		err = cmd.Wait()
+		run.exited()
+		if err != nil {
+			return fmt.Errorf("%s: %w", hook, err)
+		}
+	}
+	return nil
+}
+
+// recordOutcome records how an install that began at started ended, for monitoring and auditing
+func recordOutcome(started time.Time, installErr error) {
+	writeCompletionMarker(installErr)
+	attempt := installAttempt{
+		Time:     started,
+		Disk:     mainModel.disk,
+		Duration: time.Since(started),
+		Success:  installErr == nil,
+	}
+	if installErr != nil {
+		attempt.Error = installErr.Error()
+	}
+	if err := appendAttempt(attempt); err != nil {
+		mainModel.log.Printf("Error recording install attempt to %s: %v", attemptLogPath, err)
+	}
+}
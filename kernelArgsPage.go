@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// validateKernelArgs checks that the extra boot arguments can be appended to the kernel command line as is
+func validateKernelArgs(value string) error {
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			return errors.New("boot arguments can't contain newlines or control characters")
+		}
+	}
+	if strings.Count(value, `"`)%2 != 0 {
+		return errors.New("unbalanced quotes")
+	}
+	return nil
+}
+
+// Kernel Arguments Page, extra arguments appended to the kernel command line of the installed system
+type kernelArgsPage struct {
+	input textinput.Model
+	err   error
+}
+
+func newKernelArgsPage() *kernelArgsPage {
+	input := textinput.New()
+	input.Placeholder = "console=ttyS0,115200 nomodeset"
+	input.Width = 60
+	input.Focus()
+	return &kernelArgsPage{input: input}
+}
+
+func (p *kernelArgsPage) Init() tea.Cmd {
+	p.input.SetValue(mainModel.kernelArgs)
+	p.err = nil
+	return textinput.Blink
+}
+
+func (p *kernelArgsPage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			value := strings.TrimSpace(p.input.Value())
+			if p.err = validateKernelArgs(value); p.err != nil {
+				mainModel.log.Printf("Invalid boot arguments %q: %v", value, p.err)
+				return p, nil
+			}
+			mainModel.log.Printf("Setting extra boot arguments to %q", value)
+			mainModel.kernelArgs = value
+			return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+		case "esc":
+			// Go back to customization page
+			return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+		}
+	}
+
+	p.input, cmd = p.input.Update(msg)
+	return p, cmd
+}
+
+func (p *kernelArgsPage) View() string {
+	s := "Kernel Arguments\n\n"
+	s += "Extra arguments appended to the kernel command line of the installed system:\n"
+	s += p.input.View() + "\n\n"
+	s += "Leave empty to keep the defaults.\n"
+
+	if p.err != nil {
+		s += "\n" + lipgloss.NewStyle().Foreground(kairosHighlight2).Render("Invalid boot arguments: "+p.err.Error()) + "\n"
+	}
+
+	return s
+}
+
+func (p *kernelArgsPage) Title() string {
+	return "Kernel Arguments"
+}
+
+func (p *kernelArgsPage) Help() string {
+	return "enter: save • esc: cancel"
+}
+
+func (p *kernelArgsPage) ID() string { return "kernel_args" }
+
+func (p *kernelArgsPage) BackTarget() string { return "customization" }
+
+func (p *kernelArgsPage) TakingTextInput() bool { return true }
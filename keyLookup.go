@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyLookupDelay is how long typing has to pause before a shorthand key is looked up, so the provider
+// is not asked about every prefix of the user name
+const keyLookupDelay = 500 * time.Millisecond
+
+// keyProviderURLs are where each provider publishes the keys of a user
+var keyProviderURLs = map[string]string{
+	"github:": "https://github.com/%s.keys",
+	"gitlab:": "https://gitlab.com/%s.keys",
+}
+
+// keyLookupTickMsg fires once typing paused, seq tells whether the input changed since
+type keyLookupTickMsg struct {
+	seq int
+	key string
+}
+
+// keyLookupMsg is the outcome of looking up the keys behind a shorthand
+type keyLookupMsg struct {
+	key   string
+	count int
+	err   error
+}
+
+// debounceKeyLookup waits for keyLookupDelay before asking for the lookup of key
+func debounceKeyLookup(seq int, key string) tea.Cmd {
+	return tea.Tick(keyLookupDelay, func(time.Time) tea.Msg { return keyLookupTickMsg{seq: seq, key: key} })
+}
+
+// lookupKeysCmd counts the keys published for the shorthand in the background, until ctx is cancelled
+func lookupKeysCmd(ctx context.Context, key string) tea.Cmd {
+	return func() tea.Msg {
+		count, err := lookupKeys(ctx, key)
+		return keyLookupMsg{key: key, count: count, err: err}
+	}
+}
+
+// lookupKeys returns how many keys the provider publishes for the user of the shorthand
+func lookupKeys(ctx context.Context, key string) (int, error) {
+	provider, user, _ := strings.Cut(key, ":")
+	url, ok := keyProviderURLs[provider+":"]
+	if !ok || user == "" {
+		return 0, fmt.Errorf("%s is not a key shorthand", key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(url, user), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := newHTTPClient().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, fmt.Errorf("no user %s on %s", user, provider)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return 0, fmt.Errorf("%s returned %s", provider, resp.Status)
+	}
+	count := 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) != "" {
+			count++
+		}
+	}
+	return count, scanner.Err()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+)
+
+// keyBinding is a key and what it does, as listed in the shortcut reference
+type keyBinding struct {
+	key  string
+	desc string
+}
+
+// globalKeys returns the bindings available on every page but the install
+func globalKeys() []keyBinding {
+	return []keyBinding{
+		{"esc", "back"},
+		{forwardKey, "forward"},
+		{"ctrl+y", "view config, c in it copies it to the clipboard"},
+		{"ctrl+t", "high contrast"},
+		{":", "go to page"},
+		{"?", "all shortcuts"},
+		{shellKey, "quit to a shell"},
+		{"q/ctrl+c", "quit"},
+	}
+}
+
+// helpBindings splits a page help line ("key: action • key: action") into its bindings. Parts that are
+// not a binding are kept as a note with no key.
+func helpBindings(help string) []keyBinding {
+	var bindings []keyBinding
+	for _, part := range strings.Split(help, "•") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, desc, found := strings.Cut(part, ": ")
+		if !found {
+			bindings = append(bindings, keyBinding{desc: part})
+			continue
+		}
+		bindings = append(bindings, keyBinding{key: key, desc: desc})
+	}
+	return bindings
+}
+
+// keyHelpView renders every shortcut available on the given page and the global ones
+func keyHelpView(p Page) string {
+	s := "Keyboard shortcuts\n\n"
+	s += keyTable(p.Title(), helpBindings(p.Help())) + "\n\n"
+	s += keyTable("Everywhere", globalKeys())
+	return s
+}
+
+// keyTable renders a titled table of key bindings
+func keyTable(title string, bindings []keyBinding) string {
+	rows := make([][]string, 0, len(bindings))
+	for _, b := range bindings {
+		rows = append(rows, []string{b.key, b.desc})
+	}
+	header := lipgloss.NewStyle().Bold(true).Foreground(kairosHighlight).Padding(0, 1)
+	key := lipgloss.NewStyle().Foreground(kairosAccent).Padding(0, 1)
+	cell := lipgloss.NewStyle().Foreground(kairosText).Padding(0, 1)
+	t := table.New().
+		Border(glyphs.Border).
+		BorderStyle(lipgloss.NewStyle().Foreground(kairosBorder)).
+		Headers(title, "").
+		Rows(rows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			switch {
+			case row == table.HeaderRow:
+				return header
+			case col == 0:
+				return key
+			}
+			return cell
+		})
+	return t.Render()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// wideLayoutWidth is the terminal width from which the help moves to a column next to the page content,
+// single column content looks sparse on terminals that wide
+const wideLayoutWidth = 140
+
+// sidePanelWidth is the width of the column next to the page content on wide terminals
+const sidePanelWidth = 40
+
+// wideLayout reports whether the terminal is wide enough for two columns. Screen readers get the
+// linear layout whatever the width.
+func wideLayout() bool {
+	return !mainModel.accessible && mainModel.width >= wideLayoutWidth
+}
+
+// sideColumnWidth is the width of the column next to the page content, 0 when there is none
+func sideColumnWidth() int {
+	switch {
+	case wideLayout():
+		return sidePanelWidth
+	case debugPanelShown():
+		return debugPanelWidth
+	}
+	return 0
+}
+
+// sidePanel renders a titled box of the side column
+func sidePanel(title, body string) string {
+	width := sideColumnWidth() - 2 // Border
+	return lipgloss.NewStyle().
+		Border(glyphs.Border).
+		BorderForeground(kairosBorder).
+		Width(width).
+		Padding(0, 1).
+		Render(lipgloss.NewStyle().Bold(true).Foreground(kairosHighlight).Render(title) + "\n" + ansi.Wrap(body, width-2, ""))
+}
+
+// helpPanel lists the shortcuts of the help line one per line, for the side column
+func helpPanel(help string) string {
+	return sidePanel("Keys", strings.Join(strings.Split(help, " • "), "\n"))
+}
+
+// withSideColumn puts the panels in a column to the right of the content
+func withSideColumn(content string, panels []string) string {
+	if len(panels) == 0 {
+		return content
+	}
+	left := lipgloss.NewStyle().Width(contentWidth() + 1).Render(content)
+	return lipgloss.JoinHorizontal(lipgloss.Top, left, lipgloss.JoinVertical(lipgloss.Left, panels...))
+}
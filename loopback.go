@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// loopbackImagePath is the file backing the loop device in safe mode. It is kept after exiting, so the
+// installed system can be booted in a VM.
+var loopbackImagePath = filepath.Join(os.TempDir(), "kairos-loopback.img")
+
+// setupLoopback creates a sparse file of the given size and attaches it to a free loop device, which is returned
+func setupLoopback(path string, sizeMiB uint64) (string, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	// Truncating allocates nothing, the file only takes the space the install writes
+	if err := f.Truncate(int64(sizeMiB) * 1024 * 1024); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	out, err := exec.Command("losetup", "--find", "--show", path).Output()
+	if err != nil {
+		return "", fmt.Errorf("attaching %s to a loop device: %w", path, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// detachLoopback detaches the loop device set up for safe mode
+func detachLoopback(device string) {
+	if err := exec.Command("losetup", "--detach", device).Run(); err != nil {
+		mainModel.log.Printf("Error detaching %s: %v", device, err)
+		return
+	}
+	mainModel.log.Printf("Detached %s", device)
+}
@@ -1,19 +1,56 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
-var version = "0.0.1" // Placeholder for version, can be set during build
+// Build information, set during build through ldflags
+var (
+	version = "0.0.1"
+	commit  = "unknown"
+	date    = "unknown"
+)
+
+// versionString describes the build, for support tickets
+func versionString() string {
+	return fmt.Sprintf("%s (commit %s, built %s)", version, commit, date)
+}
 
 // Main function
 func main() {
+	inline := flag.Bool("inline", false, "Render inline instead of using the alternate screen, preserving the terminal scrollback")
+	accessible := flag.Bool("accessible", false, "Render plain text without colors or borders, for screen readers")
+	highContrast := flag.Bool("high-contrast", false, "Use the high contrast theme")
+	skipWelcome := flag.Bool("skip-welcome", false, "Start straight at the disk selection, even if the branding provides a welcome page")
+	skipConfirmation := flag.Bool("skip-confirmation", false, "Don't ask for confirmation before wiping the selected disk")
+	auto := flag.Bool("auto", false, "Install without interaction when there is exactly one suitable disk, continuing with the defaults")
+	unattendedTimeout := flag.Duration("unattended-timeout", 0, "Continue with the defaults after this long without input, e.g. 30s. Disabled by default")
+	unattendedPages := flag.String("unattended-pages", defaultUnattendedPages, "Comma separated IDs of the pages continuing on their own after the unattended timeout")
+	profileName := flag.String("profile", "", "Load the answers from a profile saved from the summary page, by name or path")
+	flag.StringVar(&attemptLogPath, "attempt-log", attemptLogPath, "File every install attempt is recorded to")
+	flag.StringVar(&completionMarkerPath, "completion-marker", completionMarkerPath, "File written with the outcome once the install ends, empty to disable")
+	flag.StringVar(&profileDir, "profile-dir", profileDir, "Directory profiles are saved to and looked up in")
+	headless := flag.Bool("headless", false, "Install without the TUI using the answers from --config, streaming the installer output")
+	configFile := flag.String("config", "", "Answers to install with in --headless mode, in the same format as a saved profile")
+	allowEmptyPassword := flag.Bool("allow-empty-password", false, "In --headless mode, install the users --config has no password for instead of failing, e.g. to log in with SSH keys only")
+	installTimeout := flag.Duration("install-timeout", defaultInstallTimeout, "Stop the installer if it runs for longer than this, 0 to wait forever")
+	loopbackSize := flag.String("loopback", "", "Safe mode: install to a sparse file of this size, e.g. 20G, attached to a loop device, instead of a real disk")
+	fakeInstaller := flag.Bool("fake-installer", false, "Simulate the install instead of running kairos-agent, to try out the installer without touching any disk")
+	debug := flag.Bool("debug", false, "Show the navigation stack and internal state, and log in more detail, to diagnose issues and develop new pages")
+	flag.StringVar(&forwardKey, "forward-key", forwardKey, "Key going forward again to the page last backed out of with ESC, e.g. ctrl+f or alt+right")
+	showVersion := flag.Bool("version", false, "Print the version, commit and build date and exit")
+	flag.Parse()
+
 	// if we have an arg and that arg is version or v, print the version and exit
-	if len(os.Args) > 1 && (os.Args[1] == "version" || os.Args[1] == "v") {
-		fmt.Println(version)
+	if *showVersion || flag.NArg() > 0 && (flag.Arg(0) == "version" || flag.Arg(0) == "v") {
+		fmt.Println(versionString())
 		os.Exit(0)
 	}
 
@@ -22,10 +59,125 @@ func main() {
 		fmt.Println("This program must be run as root. Please use 'sudo' or run as root user.")
 		os.Exit(1)
 	}
+	if *fakeInstaller {
+		installer = newFakeRunner(time.Second)
+	}
+	if *headless {
+		if *configFile == "" {
+			fmt.Println("--headless requires --config")
+			os.Exit(1)
+		}
+		if err := runHeadless(*configFile, *installTimeout, *allowEmptyPassword); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			if hint := errorGuidance(err); hint != "" {
+				fmt.Println(hint)
+			}
+			os.Exit(exitFailed)
+		}
+		os.Exit(exitSucceeded)
+	}
 	mainModel = initialModel()
-	p := tea.NewProgram(mainModel, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	mainModel.inline = *inline
+	mainModel.installTimeout = *installTimeout
+	mainModel.skipWelcome = *skipWelcome
+	if *skipWelcome && mainModel.currentPageID == "welcome" {
+		mainModel.log.Printf("Skipping welcome page")
+		mainModel.currentPageID = "disk_selection"
+	}
+	mainModel.accessible = *accessible
+	if *debug {
+		enableDebug()
+	}
+	if *profileName != "" {
+		prof, err := loadProfile(*profileName)
+		if err != nil {
+			fmt.Printf("Error loading profile %s: %v\n", *profileName, err)
+			os.Exit(1)
+		}
+		mainModel.log.Printf("Loaded profile %s", *profileName)
+		applyProfile(prof)
+	}
+	// Attached last, so nothing exits early leaving the loop device behind. It is detached once the TUI ends.
+	if *loopbackSize != "" {
+		size, err := parseSizeMiB(*loopbackSize)
+		if err != nil {
+			fmt.Printf("Invalid --loopback size: %v\n", err)
+			os.Exit(exitFailed)
+		}
+		device, err := setupLoopback(loopbackImagePath, size)
+		if err != nil {
+			fmt.Printf("Error setting up the loopback disk: %v\n", err)
+			os.Exit(exitFailed)
+		}
+		mainModel.log.Printf("Safe mode, installing to %s backed by %s", device, loopbackImagePath)
+		mainModel.loopback = device
+		preselectDisk(device)
+	}
+	if *highContrast || DefaultTheme() == highContrastTheme.Name {
+		applyTheme(highContrastTheme)
+	}
+	if mainModel.accessible {
+		// No colors, styling or box drawing at all
+		lipgloss.SetColorProfile(termenv.Ascii)
+		glyphs = asciiGlyphs
+	}
+	if *skipConfirmation {
+		// Only skip the wipe confirmation when nobody is there to pick a disk by mistake
+		if isInteractive() && mainModel.disk == "" && mainModel.preselectedDisk == "" {
+			mainModel.log.Printf("Ignoring --skip-confirmation: interactive terminal and no disk preselected")
+			fmt.Println("Warning: ignoring --skip-confirmation as the terminal is interactive and no disk was preselected")
+		} else {
+			mainModel.log.Printf("Disk wipe confirmation will be skipped")
+			mainModel.skipConfirmation = true
+		}
+	}
+	if *auto {
+		mainModel.log.Printf("Automatic install requested")
+		// Nobody is there to confirm, the disk is only picked without confirmation when there is no
+		// doubt, see autoSelect. A disk picked by hand is still confirmed.
+		mainModel.auto = true
+		if *unattendedTimeout == 0 {
+			*unattendedTimeout = autoAdvanceDelay
+		}
+	}
+	if *unattendedTimeout > 0 {
+		mainModel.unattendedTimeout = *unattendedTimeout
+		mainModel.unattendedPages = parseUnattendedPages(*unattendedPages)
+		mainModel.log.Printf("Unattended timeout of %s on pages %s", *unattendedTimeout, *unattendedPages)
+	}
+	// Signals are handled by forwardSignals, so a running install is stopped too
+	opts := []tea.ProgramOption{tea.WithoutSignalHandler()}
+	if !mainModel.inline {
+		opts = append(opts, tea.WithAltScreen())
+	}
+	p := tea.NewProgram(mainModel, opts...)
+	forwardSignals(p)
+	_, err := p.Run()
+	if mainModel.loopback != "" {
+		detachLoopback(mainModel.loopback)
+		fmt.Printf("The loopback disk image is kept at %s\n", loopbackImagePath)
+	}
+	if err != nil {
 		fmt.Printf("Error: %v", err)
-		os.Exit(1)
+		os.Exit(exitFailed)
+	}
+	if mainModel.dropToShell {
+		// The shell takes over the process, its exit code is reported instead of exitCode(mainModel),
+		// which is always the one for not installing at this point
+		fmt.Println("Type exit to leave the shell. Run the installer again to install.")
+		if err := execShell(); err != nil {
+			fmt.Printf("Error starting a shell: %v\n", err)
+			os.Exit(exitFailed)
+		}
+	}
+	os.Exit(exitCode(mainModel))
+}
+
+// isInteractive reports whether stdin is a terminal
+func isInteractive() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return false
 	}
+	return fi.Mode()&os.ModeCharDevice != 0
 }
@@ -5,7 +5,11 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -18,6 +22,13 @@ func newLogger() *log.Logger {
 	return log.New(f, "", log.LstdFlags)
 }
 
+// forwardKey goes forward again to the page last backed out of with ESC, set with --forward-key. It is
+// left to text inputs while they have the focus, ctrl+f moves their cursor.
+var forwardKey = "ctrl+f"
+
+// quitWindow is how close two ctrl+c presses have to be to skip the quit confirmation
+const quitWindow = time.Second
+
 // NextPageMsg is a custom message type for page navigation
 type NextPageMsg struct{}
 
@@ -29,20 +40,63 @@ type GoToPageMsg struct {
 
 // Main application model
 type model struct {
-	pages           []Page
-	currentPageID   string   // Track current page by ID
-	navigationStack []string // Stack to track navigation history by ID
-	width           int
-	height          int
-	title           string
-	disk            string // Selected disk
-	username        string
-	sshKeys         []string // Store SSH keys
-	password        string
-	extraFields     map[string]any // Dynamic fields for customization
-	log             *log.Logger
-
-	showAbortConfirm bool // Show abort confirmation popup
+	pages            []Page
+	currentPageID    string   // Track current page by ID
+	navigationStack  []string // Stack to track navigation history by ID
+	forwardStack     []string // Pages backed out of with ESC, to go forward again
+	width            int
+	height           int
+	title            string
+	disk             string                   // Selected disk, as written in the config
+	selectedDisk     diskStruct               // Details of the selected disk
+	preselectedDisk  string                   // Disk requested on the kernel command line, if any
+	users            []userAccount            // Users to create, the first one is the primary user
+	kernelArgs       string                   // Extra arguments for the kernel command line of the installed system
+	filesystem       string                   // Filesystem of the persistent partition, the agent default if empty
+	imageMirror      string                   // OCI image to install from a local registry on air-gapped networks, the default image if empty
+	customSteps      []stageStep              // Provisioning steps added to the stages by hand, after the ones the installer sets up
+	persistentSize   uint64                   // Size of the persistent partition in MiB, the rest of the disk if 0
+	loopback         string                   // Loop device installed to in safe mode, instead of a real disk
+	dropToShell      bool                     // Start a shell once the TUI exits
+	baseConfig       map[string]any           // Cloud-config the answers are merged into, if any
+	baseConfigURL    string                   // Where baseConfig was fetched from
+	baseConfigSHA256 string                   // Expected digest baseConfig was verified against, if any
+	defaultHostname  string                   // Generated hostname, used unless one is given
+	lastAttempt      *installAttempt          // Outcome of the previous install attempt on this machine, if any
+	outcome          installOutcome           // How the install of this session ended
+	installTimeout   time.Duration            // Stop the installer if it runs for longer than this, 0 disables it
+	pluginSteps      []InstallStep            // Extra install steps added by the plugins
+	extraFields      map[string]any           // Dynamic fields for customization
+	inputHistory     map[string]*inputHistory // Values submitted to each text field, by field name
+	log              *log.Logger
+	inline           bool // Render inline instead of taking over the whole screen
+	accessible       bool // Render plain linear text for screen readers
+	debug            bool // Show the navigation stack and internal state, set with --debug
+
+	skipConfirmation bool // Don't ask for confirmation before wiping the selected disk
+	wipeConfirmed    bool // The wipe of disk was confirmed, or skipped with skipConfirmation
+	skippedConfirm   bool // The wipe confirmation was skipped for the selected disk
+	skipWelcome      bool // Start at the disk selection even if there is something to welcome with
+	auto             bool // Install without interaction when the target disk is unambiguous
+	rescue           bool // Rescue an existing installation instead of installing
+
+	unattendedTimeout time.Duration   // Continue with the defaults after this long without input, 0 disables it
+	unattendedPages   map[string]bool // Pages that continue on their own after the unattended timeout
+	idleGen           int             // Bumped on every key press, to tell stale idle timers apart
+
+	showAbortConfirm   bool            // Show abort confirmation popup
+	showConfigPreview  bool            // Show the generated config overlay
+	previewStatus      string          // Outcome of copying the config from the overlay
+	previewView        viewport.Model  // Scrollable view of the config overlay
+	showQuitConfirm    bool            // Show quit confirmation popup
+	quitToShell        bool            // The quit popup was opened with shellKey, confirming drops to a shell
+	showDiscardConfirm bool            // Show the popup asking to discard the unsaved input of the page
+	disabledPages      map[string]bool // Pages turned off by the branding
+	palette            *commandPalette // Open command palette, if any
+	showKeyHelp        bool            // Show the keyboard shortcut reference
+	probing            bool            // Probing the hardware on startup, showing the splash
+	splash             spinner.Model   // Spinner of the splash
+	lastCtrlC          time.Time       // When ctrl+c was last pressed, to allow quitting by pressing it twice
 }
 
 var mainModel model
@@ -53,35 +107,158 @@ func initialModel() model {
 	mainModel = model{
 		navigationStack: []string{},
 		title:           DefaultTitle(),
+		defaultHostname: defaultHostname(),
 		log:             newLogger(),
+		probing:         true,
+		splash:          newSplashSpinner(),
+	}
+	if dir := brandingDir(); dir != "" {
+		mainModel.log.Printf("Using branding from %s", dir)
+	}
+	if attempt, ok := lastAttempt(); ok {
+		mainModel.log.Printf("%s", attempt)
+		mainModel.lastAttempt = &attempt
+	}
+	if device, ok := cmdlineValue(cmdlineDeviceKey); ok && device != "" {
+		mainModel.preselectedDisk = device
 	}
 	mainModel.pages = []Page{
 		newDiskSelectionPage(),
+		newConfirmationPage(),
 		newInstallOptionsPage(),
 		newCustomizationPage(),
+		newUsersPage(),
 		newUserPasswordPage(),
 		newSSHKeysPage(),
+		newHostnamePage(),
+		newKernelArgsPage(),
+		newFilesystemPage(),
+		newImageMirrorPage(),
+		newCustomStagesPage(),
+		newPersistentSizePage(),
+		newConfigURLPage(),
 		newSummaryPage(),
 		newInstallProcessPage(),
+		newRescuePage(),
 	}
+	// The welcome page also leads to the rescue, so it is always there even if it is not shown first
+	welcome := DefaultWelcome()
+	mainModel.pages = append([]Page{newWelcomePage(welcome)}, mainModel.pages...)
+	if s, ok := loadSession(); ok {
+		mainModel.log.Printf("Found saved session at %s", sessionPath)
+		mainModel.pages = append([]Page{newResumePage(s)}, mainModel.pages...)
+	}
+	mainModel.disabledPages = disabledPages(DisabledPages())
+	mainModel.pages = enabledPages(mainModel.pages)
 	mainModel.currentPageID = mainModel.pages[0].ID() // Start with first page ID
+	if mainModel.currentPageID == "welcome" && welcome == "" && EULAText() == "" {
+		// Only welcome when there is something to say or a license to accept, or an existing installation is found once probed
+		mainModel.currentPageID = "disk_selection"
+	}
 	return mainModel
 }
 
-func (m model) Init() tea.Cmd {
-	mainModel.log.Printf("Starting Kairos Interactive Installer")
-	if len(mainModel.pages) > 0 {
-		for _, p := range mainModel.pages {
-			if p.ID() == mainModel.currentPageID {
-				return p.Init()
-			}
+// requiredPages can't be disabled, the install can't go on without them
+var requiredPages = map[string]bool{
+	"disk_selection":  true,
+	"confirmation":    true, // Use --skip-confirmation instead
+	"install_options": true,
+	"summary":         true,
+	"install_process": true,
+}
+
+// disabledPages turns the list of page IDs to disable into a set, leaving out the required pages
+func disabledPages(ids []string) map[string]bool {
+	disabled := map[string]bool{}
+	for _, id := range ids {
+		if requiredPages[id] {
+			mainModel.log.Printf("Page %s is required and can't be disabled", id)
+			continue
+		}
+		mainModel.log.Printf("Page %s disabled by the branding", id)
+		disabled[id] = true
+	}
+	return disabled
+}
+
+// enabledPages filters out the pages disabled by the branding
+func enabledPages(pages []Page) []Page {
+	var enabled []Page
+	for _, p := range pages {
+		if !mainModel.disabledPages[p.ID()] {
+			enabled = append(enabled, p)
+		}
+	}
+	return enabled
+}
+
+// pageExists reports whether a page with the given ID is in mainModel.pages
+func pageExists(pageID string) bool {
+	for _, p := range mainModel.pages {
+		if p.ID() == pageID {
+			return true
+		}
+	}
+	return false
+}
+
+// replacePage swaps the page with the same ID in mainModel.pages for the given one
+func replacePage(page Page) {
+	for i, p := range mainModel.pages {
+		if p.ID() == page.ID() {
+			mainModel.pages[i] = page
+			return
 		}
 	}
+	mainModel.log.Printf("replacePage: pageID=%s not found in mainModel.pages", page.ID())
+}
+
+// currentPage returns the page being shown
+func currentPage() Page {
+	for _, p := range mainModel.pages {
+		if p.ID() == mainModel.currentPageID {
+			return p
+		}
+	}
+	return nil
+}
+
+func (m model) Init() tea.Cmd {
+	mainModel.log.Printf("Starting Kairos Interactive Installer %s", versionString())
+	if p := currentPage(); p != nil {
+		return tea.Batch(mainModel.splash.Tick, probeHardwareCmd(), p.Init(), restartIdleTimer())
+	}
 
 	return nil
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	debugMsg(msg)
+	switch msg := msg.(type) {
+	case idleTimeoutMsg:
+		return mainModel, handleIdleTimeout(msg)
+	case signalMsg:
+		return mainModel, handleSignal(msg)
+	}
+	var splashCmd tea.Cmd
+	if mainModel.probing {
+		var handled bool
+		if splashCmd, handled = updateSplash(msg); handled {
+			return mainModel, splashCmd
+		}
+	}
+
+	// Any key press or page change restarts the unattended timeout
+	pageID := mainModel.currentPageID
+	_, isKey := msg.(tea.KeyMsg)
+	updated, cmd := m.update(msg)
+	if isKey || mainModel.currentPageID != pageID {
+		cmd = tea.Batch(cmd, restartIdleTimer())
+	}
+	return updated, tea.Batch(cmd, splashCmd)
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// For navigation, access the mainModel so we can modify from anywhere
 	currentIdx := -1
 	for i, p := range mainModel.pages {
@@ -94,11 +271,95 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return mainModel, nil
 	}
 
+	// Only handle y/n/esc while the discard popup is open, block other keys
+	if keyMsg, isKey := msg.(tea.KeyMsg); isKey && mainModel.showDiscardConfirm {
+		switch keyMsg.String() {
+		case "y", "Y":
+			mainModel.showDiscardConfirm = false
+			if page, ok := currentPage().(unsavedPage); ok {
+				page.DiscardInput()
+			}
+			return mainModel, goBack()
+		case "n", "N", "esc":
+			mainModel.showDiscardConfirm = false
+		}
+		return mainModel, nil
+	}
+
+	// Only handle y/n/esc while the quit popup is open, block other keys
+	if keyMsg, isKey := msg.(tea.KeyMsg); isKey && mainModel.showQuitConfirm {
+		switch keyMsg.String() {
+		case "y", "Y":
+			if mainModel.quitToShell {
+				return mainModel, quitToShell()
+			}
+			return mainModel, tea.Quit
+		case "ctrl+c":
+			if time.Since(mainModel.lastCtrlC) < quitWindow {
+				return mainModel, tea.Quit
+			}
+			mainModel.lastCtrlC = time.Now()
+		case "n", "N", "esc":
+			mainModel.showQuitConfirm = false
+			mainModel.quitToShell = false
+		}
+		return mainModel, nil
+	}
+
+	// The config preview overlay is available from any page and swallows keys while open
+	if keyMsg, isKey := msg.(tea.KeyMsg); isKey && !mainModel.showAbortConfirm && !mainModel.showKeyHelp {
+		if mainModel.showConfigPreview {
+			switch keyMsg.String() {
+			case "ctrl+y", "esc":
+				mainModel.showConfigPreview = false
+				return mainModel, nil
+			case "c":
+				mainModel.previewStatus = copyConfigToClipboard()
+				refreshConfigPreview()
+				return mainModel, nil
+			case "pgup":
+				mainModel.previewView.PageUp()
+				return mainModel, nil
+			case "pgdown":
+				mainModel.previewView.PageDown()
+				return mainModel, nil
+			case "up", "k":
+				mainModel.previewView.ScrollUp(1)
+				return mainModel, nil
+			case "down", "j":
+				mainModel.previewView.ScrollDown(1)
+				return mainModel, nil
+			case "home":
+				mainModel.previewView.GotoTop()
+				return mainModel, nil
+			case "end":
+				mainModel.previewView.GotoBottom()
+				return mainModel, nil
+			case "ctrl+c":
+				// Close the overlay and let ctrl+c be handled as usual
+				mainModel.showConfigPreview = false
+			default:
+				return mainModel, nil
+			}
+		} else if keyMsg.String() == "ctrl+y" {
+			mainModel.showConfigPreview = true
+			mainModel.previewStatus = ""
+			mainModel.previewView = viewport.New(0, 0)
+			refreshConfigPreview()
+			return mainModel, nil
+		}
+		if keyMsg.String() == "ctrl+t" {
+			toggleHighContrast()
+			mainModel.log.Printf("Switched to %s theme", currentTheme.Name)
+			return mainModel, nil
+		}
+	}
+
 	// Hijack all keys if on install process page
 	if installPage, ok := mainModel.pages[currentIdx].(*installProcessPage); ok {
 		if mainModel.showAbortConfirm {
-			// Allow CheckInstallerMsg to update progress even when popup is open
-			if _, isCheck := msg.(CheckInstallerMsg); isCheck {
+			// Allow installer messages to update progress even when popup is open
+			if _, isKey := msg.(tea.KeyMsg); !isKey {
 				updatedPage, cmd := installPage.Update(msg)
 				mainModel.pages[currentIdx] = updatedPage
 				return mainModel, cmd
@@ -107,9 +368,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if keyMsg, isKey := msg.(tea.KeyMsg); isKey {
 				switch keyMsg.String() {
 				case "y", "Y":
-					installPage.Abort()
 					mainModel.showAbortConfirm = false
-					return mainModel, tea.Quit
+					return mainModel, installPage.abortAndQuit()
 				case "n", "N", "esc":
 					mainModel.showAbortConfirm = false
 					return mainModel, nil
@@ -119,11 +379,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return mainModel, nil
 		}
 		if keyMsg, isKey := msg.(tea.KeyMsg); isKey {
+			if installPage.err != nil && (keyMsg.String() == "ctrl+c" || keyMsg.String() == "q") {
+				// Failed, there is nothing left to abort
+				return mainModel, tea.Quit
+			}
 			if keyMsg.Type == tea.KeyCtrlC || keyMsg.String() == "ctrl+c" {
 				mainModel.showAbortConfirm = true
 				return mainModel, nil
 			}
 		}
+		// Once failed, the error details can be copied for a bug report
+		if keyMsg, isKey := msg.(tea.KeyMsg); isKey && (installScrollKeys[keyMsg.String()] || installPage.err != nil && keyMsg.String() == "c") {
+			updatedPage, cmd := installPage.Update(msg)
+			mainModel.pages[currentIdx] = updatedPage
+			return mainModel, cmd
+		}
 		if installPage.progress < len(installPage.steps)-1 {
 			// Ignore all key events during install
 			if _, isKey := msg.(tea.KeyMsg); isKey {
@@ -138,24 +408,105 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	// The shortcut reference closes with any key
+	if keyMsg, isKey := msg.(tea.KeyMsg); isKey {
+		if mainModel.showKeyHelp {
+			mainModel.showKeyHelp = false
+			return mainModel, nil
+		}
+		if keyMsg.String() == "?" && mainModel.palette == nil && !takingTextInput(mainModel.pages[currentIdx]) {
+			mainModel.showKeyHelp = true
+			return mainModel, nil
+		}
+	}
+
+	// The command palette takes all keys while open
+	if keyMsg, isKey := msg.(tea.KeyMsg); isKey {
+		if mainModel.palette != nil {
+			pageID, done, cmd := mainModel.palette.Update(keyMsg)
+			if done {
+				mainModel.palette = nil
+			}
+			if pageID != "" {
+				return mainModel, func() tea.Msg { return GoToPageMsg{PageID: pageID} }
+			}
+			return mainModel, cmd
+		}
+		if keyMsg.String() == shellKey && !takingTextInput(mainModel.pages[currentIdx]) {
+			// Confirmed like quitting, the answers given so far are lost as well
+			mainModel.showQuitConfirm = true
+			mainModel.quitToShell = true
+			return mainModel, nil
+		}
+		if keyMsg.String() == ":" && !takingTextInput(mainModel.pages[currentIdx]) {
+			mainModel.palette = newCommandPalette()
+			return mainModel, textinput.Blink
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		mainModel.width = msg.Width
 		mainModel.height = msg.Height
+		if mainModel.showConfigPreview {
+			refreshConfigPreview()
+		}
+		return mainModel, nil
+
+	case pluginsLoadedMsg:
+		// Answered in the background, the customization page may not be shown anymore
+		for _, page := range mainModel.pages {
+			if cp, ok := page.(*customizationPage); ok {
+				cp.pluginsLoaded(msg)
+			}
+		}
 		return mainModel, nil
 
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "q":
-			return mainModel, tea.Quit
+			if msg.String() == "q" && takingTextInput(mainModel.pages[currentIdx]) {
+				break // Typed into the input
+			}
+			if msg.String() == "ctrl+c" {
+				// ctrl+c twice in a row quits straight away
+				if time.Since(mainModel.lastCtrlC) < quitWindow {
+					return mainModel, tea.Quit
+				}
+				mainModel.lastCtrlC = time.Now()
+			}
+			mainModel.showQuitConfirm = true
+			mainModel.quitToShell = false
+			return mainModel, nil
 		case "esc":
-			// Go back to previous page if we have navigation history
-			if len(mainModel.navigationStack) > 0 {
-				// Pop the last page from the stack
-				mainModel.currentPageID = mainModel.navigationStack[len(mainModel.navigationStack)-1]
-				mainModel.navigationStack = mainModel.navigationStack[:len(mainModel.navigationStack)-1]
-				return mainModel, mainModel.pages[currentIdx].Init()
+			if page, ok := mainModel.pages[currentIdx].(promptingPage); ok && page.Prompting() {
+				break // Closes the prompt
 			}
+			if page, ok := mainModel.pages[currentIdx].(unsavedPage); ok && page.HasUnsavedInput() {
+				mainModel.showDiscardConfirm = true
+				return mainModel, nil
+			}
+			// Go back to the logical parent of the page, or the previous page
+			if cmd := goBack(); cmd != nil || mainModel.currentPageID != mainModel.pages[currentIdx].ID() {
+				return mainModel, cmd
+			}
+		case forwardKey:
+			if takingTextInput(mainModel.pages[currentIdx]) {
+				break // Handled by the input
+			}
+			// Go forward again to the page we backed out of
+			if len(mainModel.forwardStack) > 0 {
+				nextID := mainModel.forwardStack[len(mainModel.forwardStack)-1]
+				mainModel.forwardStack = mainModel.forwardStack[:len(mainModel.forwardStack)-1]
+				for _, p := range mainModel.pages {
+					if p.ID() == nextID {
+						mainModel.navigationStack = append(mainModel.navigationStack, mainModel.currentPageID)
+						mainModel.currentPageID = nextID
+						return mainModel, p.Init()
+					}
+				}
+			}
+			return mainModel, nil
 		}
 	}
 
@@ -167,8 +518,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Check if we need to navigate to next page
 		if _, ok := msg.(NextPageMsg); ok {
 			if currentIdx < len(mainModel.pages)-1 {
-				// Push current page to navigation stack
+				// Push current page to navigation stack, a new choice drops the forward history
 				mainModel.navigationStack = append(mainModel.navigationStack, mainModel.currentPageID)
+				mainModel.forwardStack = nil
 				mainModel.currentPageID = mainModel.pages[currentIdx+1].ID()
 				return mainModel, tea.Batch(cmd, mainModel.pages[currentIdx+1].Init())
 			}
@@ -176,11 +528,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Check if we need to navigate to a specific page
 		if goToPageMsg, ok := msg.(GoToPageMsg); ok {
+			if mainModel.disabledPages[goToPageMsg.PageID] {
+				mainModel.log.Printf("model.Update: not going to pageID=%s, disabled by the branding", goToPageMsg.PageID)
+				return mainModel, cmd
+			}
 			if goToPageMsg.PageID != "" {
 				for i, p := range mainModel.pages {
 					if p.ID() == goToPageMsg.PageID {
 						mainModel.navigationStack = append(mainModel.navigationStack, mainModel.currentPageID)
+						mainModel.forwardStack = nil
 						mainModel.currentPageID = goToPageMsg.PageID
+						saveSession()
 						return mainModel, tea.Batch(cmd, mainModel.pages[i].Init())
 					}
 				}
@@ -195,17 +553,20 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m model) View() string {
-	if mainModel.width == 0 || mainModel.height == 0 {
-		return "Loading..."
+	if mainModel.probing || mainModel.width == 0 || mainModel.height == 0 {
+		return splashView()
 	}
 
 	borderStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
+		Border(glyphs.Border).
 		BorderForeground(kairosBorder).
 		Background(kairosBg).
 		Padding(1).
-		Width(mainModel.width - 4).
-		Height(mainModel.height - 4)
+		Width(mainModel.width - 4)
+	if !mainModel.inline {
+		// Only fill the whole screen when we own it
+		borderStyle = borderStyle.Height(mainModel.height - 4)
+	}
 
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
@@ -218,15 +579,50 @@ func (m model) View() string {
 	// Get current page content by ID
 	content := ""
 	help := ""
+	details := ""
 	for _, p := range mainModel.pages {
 		if p.ID() == mainModel.currentPageID {
 			content = p.View()
-			help = p.Help()
+			help = p.Help() + unattendedHelp(p)
+			if dp, ok := p.(detailedPage); ok {
+				details = dp.Details()
+			}
 			break
 		}
 	}
 
+	if mainModel.palette != nil || mainModel.showConfigPreview || mainModel.showKeyHelp {
+		details = "" // Describes the page, not what covers it
+	}
+	if mainModel.palette != nil {
+		content = mainModel.palette.View()
+		help = "type to search • ↑/↓: select • enter: go to page • esc: close"
+	}
+	if mainModel.showConfigPreview {
+		content = configPreview()
+		if mainModel.previewStatus != "" {
+			content = lipgloss.NewStyle().Foreground(kairosAccent).Render(mainModel.previewStatus) + "\n\n" + content
+		}
+		help = "↑/↓/pgup/pgdown: scroll • c: copy to clipboard • ctrl+y/esc: close"
+	}
+	if mainModel.showKeyHelp {
+		if p := currentPage(); p != nil {
+			content = keyHelpView(p)
+		}
+		help = "press any key to close"
+	}
+
+	if details != "" && !wideLayout() {
+		content += "\n\n" + lipgloss.NewStyle().Faint(true).Render(details)
+	}
+
+	// Reflow long lines instead of letting them break the border
+	content = wrap(content)
+
 	title := titleStyle.Render(mainModel.title)
+	if mainModel.debug {
+		title += "\n" + debugNavView()
+	}
 
 	helpStyle := lipgloss.NewStyle().
 		Foreground(kairosText).
@@ -241,37 +637,99 @@ func (m model) View() string {
 		}
 	}
 	if currentIdx != -1 {
-		if _, ok := mainModel.pages[currentIdx].(*installProcessPage); ok {
+		if _, ok := mainModel.pages[currentIdx].(*installProcessPage); ok || mainModel.showConfigPreview || mainModel.palette != nil || mainModel.showKeyHelp {
 			fullHelp = help
 		} else {
-			fullHelp = help + " • ESC: back • q/ctrl+c: quit"
+			fullHelp = help + " • ESC: back • ?: all shortcuts • q/ctrl+c: quit"
 		}
 	}
 
-	helpText := helpStyle.Render(fullHelp)
+	helpText := helpStyle.Render(wrap(fullHelp))
+	var panels []string
+	if wideLayout() {
+		if details != "" {
+			panels = append(panels, sidePanel("Details", details))
+		}
+		// The help goes to the side column instead of under the content
+		panels = append(panels, helpPanel(fullHelp))
+		helpText = ""
+	}
 
-	availableHeight := mainModel.height - 8
-	contentHeight := availableHeight - 2
 	contentLines := strings.Split(content, "\n")
-	if len(contentLines) > contentHeight {
-		contentLines = contentLines[:contentHeight]
+	if len(contentLines) > contentHeight() {
+		contentLines = contentLines[:contentHeight()]
 		content = strings.Join(contentLines, "\n")
 	}
 
-	pageContent := fmt.Sprintf("%s\n\n%s\n\n%s", title, content, helpText)
+	if mainModel.accessible {
+		// Plain linear text, popups become a line at the end
+		s := fmt.Sprintf("%s\n\n%s\n\n%s", mainModel.title, content, fullHelp)
+		if mainModel.debug {
+			s = fmt.Sprintf("%s\n%s", debugNavView(), s)
+		}
+		if mainModel.showAbortConfirm {
+			s += "\n\nAre you sure you want to abort the installation? (y/n)"
+		}
+		if mainModel.showQuitConfirm {
+			s += "\n\n" + quitQuestion() + " (y/n)"
+		}
+		if mainModel.showDiscardConfirm {
+			s += "\n\nDiscard changes? (y/n)"
+		}
+		return s
+	}
 
+	if debugPanelShown() {
+		panels = append(panels, debugPanel())
+	}
+	content = withSideColumn(content, panels)
+
+	pageContent := fmt.Sprintf("%s\n\n%s", title, content)
+	if helpText != "" {
+		pageContent += "\n\n" + helpText
+	}
+
+	// Overlay the popups in the center
 	if mainModel.showAbortConfirm {
-		popupStyle := lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(kairosAccent).
-			Background(kairosBg).
-			Padding(1, 2).
-			Align(lipgloss.Center)
-		popupMsg := "Are you sure you want to abort the installation? (y/n)"
-		popup := popupStyle.Render(popupMsg)
-		// Overlay the popup in the center
-		return fmt.Sprintf("%s\n\n%s", borderStyle.Render(pageContent), lipgloss.Place(mainModel.width, mainModel.height, lipgloss.Center, lipgloss.Center, popup))
+		return fmt.Sprintf("%s\n\n%s", borderStyle.Render(pageContent), confirmPopup("Are you sure you want to abort the installation?"))
+	}
+	if mainModel.showQuitConfirm {
+		return fmt.Sprintf("%s\n\n%s", borderStyle.Render(pageContent), confirmPopup(quitQuestion()))
+	}
+	if mainModel.showDiscardConfirm {
+		return fmt.Sprintf("%s\n\n%s", borderStyle.Render(pageContent), confirmPopup("Discard changes?"))
 	}
 
 	return borderStyle.Render(pageContent)
 }
+
+// quitQuestion is what the quit popup asks, depending on whether it leaves for a shell
+func quitQuestion() string {
+	if mainModel.quitToShell {
+		return "Quit to a shell and discard progress?"
+	}
+	return "Quit and discard progress?"
+}
+
+// configPreview renders the config overlay, the config itself scrolls in previewView
+func configPreview() string {
+	return "Generated configuration (read-only, secrets masked)\n\n" + mainModel.previewView.View()
+}
+
+// refreshConfigPreview renders the config that would be generated from the current model state into the
+// overlay, sized to what is left of the content under its heading and the copy status
+func refreshConfigPreview() {
+	out, err := NewInstallConfig(mainModel).MaskedYAML()
+	if err != nil {
+		mainModel.log.Printf("Error rendering config preview: %v", err)
+		out = fmt.Sprintf("Error rendering config: %v", err)
+	}
+	height := contentHeight() - 2
+	if mainModel.previewStatus != "" {
+		height -= 2
+	}
+	mainModel.previewView.Width = contentWidth()
+	mainModel.previewView.Height = max(height, 1)
+	// Wrapped beforehand so the view keeps its height
+	mainModel.previewView.SetContent(wrap(strings.TrimSuffix(out, "\n")))
+}
@@ -0,0 +1,28 @@
+package main
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// goBack leaves the current page for its back target, or the previous page if it has none
+func goBack() tea.Cmd {
+	target := currentPage().BackTarget()
+	if target != "" && (!pageExists(target) || mainModel.disabledPages[target]) {
+		target = ""
+	}
+	if target == "" {
+		if len(mainModel.navigationStack) == 0 {
+			return nil
+		}
+		target = mainModel.navigationStack[len(mainModel.navigationStack)-1]
+	}
+	// Drop the history up to the target, so going back again continues from there
+	for i := len(mainModel.navigationStack) - 1; i >= 0; i-- {
+		if mainModel.navigationStack[i] == target {
+			mainModel.navigationStack = mainModel.navigationStack[:i]
+			break
+		}
+	}
+	// Remember where we were so we can go forward again
+	mainModel.forwardStack = append(mainModel.forwardStack, mainModel.currentPageID)
+	mainModel.currentPageID = target
+	return currentPage().Init()
+}
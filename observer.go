@@ -0,0 +1,26 @@
+package main
+
+// InstallObserver is told about the progress of the install. It lets tools embedding the installer, or
+// running it without the TUI, react to the install without scraping the screen.
+// The methods are called from the UI loop, so they should return quickly.
+type InstallObserver interface {
+	OnStep(step string)   // The installer moved on to the given step
+	OnOutput(line string) // A line of the installer output
+	OnComplete()          // The install finished successfully
+	OnError(err error)    // The install failed or was aborted
+}
+
+// installObservers are the registered observers, in registration order
+var installObservers []InstallObserver
+
+// RegisterInstallObserver adds an observer to be told about the progress of the install
+func RegisterInstallObserver(o InstallObserver) {
+	installObservers = append(installObservers, o)
+}
+
+// notifyObservers calls fn for each registered observer
+func notifyObservers(fn func(InstallObserver)) {
+	for _, o := range installObservers {
+		fn(o)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+// installOutcome is how the install ended, reported through the exit code
+type installOutcome int
+
+const (
+	outcomeNotRun    installOutcome = iota // Quit before installing
+	outcomeSucceeded                       // The install finished successfully
+	outcomeFailed                          // The installer failed
+	outcomeAborted                         // The user aborted the install
+	outcomeSignaled                        // Stopped by a signal
+)
+
+// Exit codes for each outcome, so orchestrators can tell what happened
+const (
+	exitSucceeded = 0
+	exitFailed    = 1
+	exitAborted   = 2
+	exitSignaled  = 130 // As shells report SIGINT
+)
+
+// exitCode returns the exit code for the outcome of the session. Leaving before installing counts as
+// aborting, except in rescue mode where there is no install to run.
+func exitCode(m model) int {
+	switch m.outcome {
+	case outcomeSucceeded:
+		return exitSucceeded
+	case outcomeFailed:
+		return exitFailed
+	case outcomeSignaled:
+		return exitSignaled
+	case outcomeNotRun:
+		if m.rescue {
+			return exitSucceeded
+		}
+	}
+	return exitAborted
+}
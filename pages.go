@@ -1,6 +1,14 @@
 package main
 
-import tea "github.com/charmbracelet/bubbletea"
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
 
 // Page interface that all pages must implement
 type Page interface {
@@ -10,4 +18,121 @@ type Page interface {
 	Title() string
 	Help() string
 	ID() string // Unique identifier for the page
+	// BackTarget is the ID of the logical parent of the page, where ESC leads from it. Pages reached from
+	// several places return "" to go back to whatever page came before.
+	BackTarget() string
+}
+
+// noBackTarget is embedded by pages without a logical parent, ESC takes them back to the previous page
+type noBackTarget struct{}
+
+func (noBackTarget) BackTarget() string { return "" }
+
+// textEntryPage is implemented by pages that can be taking free text input, so global shortcuts bound to
+// printable keys don't steal keystrokes from them
+type textEntryPage interface {
+	TakingTextInput() bool
+}
+
+// takingTextInput reports whether the page is currently taking free text input
+func takingTextInput(p Page) bool {
+	t, ok := p.(textEntryPage)
+	return ok && t.TakingTextInput()
+}
+
+// listItem renders entry i of a list of total entries, marking it when it is under the cursor.
+// In accessible mode it announces the position instead, so screen readers can follow along.
+func listItem(i, cursor, total int, label string) string {
+	if mainModel.accessible {
+		if i == cursor {
+			return fmt.Sprintf("Option %d of %d, selected: %s", i+1, total, label)
+		}
+		return fmt.Sprintf("Option %d of %d: %s", i+1, total, label)
+	}
+	marker := " "
+	if i == cursor {
+		marker = lipgloss.NewStyle().Foreground(kairosAccent).Render(">")
+		if currentTheme.Bold {
+			label = lipgloss.NewStyle().Bold(true).Render(label)
+		}
+	}
+	return fmt.Sprintf("%s %s", marker, label)
+}
+
+// detailedPage is implemented by list pages that describe the option under the cursor at more length.
+// The description goes to the side column on wide terminals and under the list otherwise.
+type detailedPage interface {
+	Details() string // Empty when there is nothing more to say
+}
+
+// unsavedPage is implemented by pages where input can be typed and not saved yet. Leaving them with ESC
+// asks before throwing that input away.
+type unsavedPage interface {
+	HasUnsavedInput() bool
+	DiscardInput() // Reverts the input to the last saved value
+}
+
+// promptingPage is implemented by pages that can show a prompt of their own. ESC is left to the page while
+// it is shown, to close the prompt and stay on the page.
+type promptingPage interface {
+	Prompting() bool
+}
+
+// contentWidth is the width available to page content inside the border and its padding
+func contentWidth() int {
+	if side := sideColumnWidth(); side > 0 {
+		return max(mainModel.width-6-side-1, 1)
+	}
+	return max(mainModel.width-6, 1)
+}
+
+// contentHeight returns how many lines of page content fit in the terminal, once the title, help and
+// border are drawn
+func contentHeight() int {
+	if mainModel.debug {
+		return max(mainModel.height-11, 1) // The navigation stack is shown under the title
+	}
+	return max(mainModel.height-10, 1)
+}
+
+// wrap reflows text to fit the content width, keeping its line breaks and styling. Words longer than
+// a line are broken up.
+func wrap(s string) string {
+	if mainModel.width == 0 {
+		return s
+	}
+	return ansi.Wrap(s, contentWidth(), "")
+}
+
+// inputView renders the input with a hint below when its value is longer than the input is wide, as it
+// scrolls sideways with no other sign the rest of the value is there
+func inputView(input textinput.Model) string {
+	n := utf8.RuneCountInString(input.Value())
+	if input.Width <= 0 || n <= input.Width {
+		return input.View()
+	}
+	hint := fmt.Sprintf("%d characters, not all shown (←/→, home/end to scroll)", n)
+	return input.View() + "\n" + lipgloss.NewStyle().Faint(true).Render(hint)
+}
+
+// fieldLabel renders the label of an input in a group of inputs, highlighting the one being typed into
+func fieldLabel(label string, focused bool) string {
+	if mainModel.accessible {
+		if focused {
+			return label + " (editing)"
+		}
+		return label
+	}
+	if focused {
+		return lipgloss.NewStyle().Foreground(kairosAccent).Bold(true).Render("> " + label)
+	}
+	return lipgloss.NewStyle().Faint(true).Render("  " + label)
+}
+
+// cycleFocus moves the focus delta inputs forward (or backward if negative) in a group of inputs, wrapping
+// around at both ends. It returns the index of the newly focused input.
+func cycleFocus(inputs []*textinput.Model, focused, delta int) (int, tea.Cmd) {
+	inputs[focused].Blur()
+	focused = ((focused+delta)%len(inputs) + len(inputs)) % len(inputs)
+	return focused, inputs[focused].Focus()
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// commandPalette lets the user jump to any page by searching for its title
+type commandPalette struct {
+	input   textinput.Model
+	cursor  int
+	matches []Page
+}
+
+func newCommandPalette() *commandPalette {
+	input := textinput.New()
+	input.Placeholder = "Type to search pages"
+	input.Width = 40
+	input.Focus()
+	c := &commandPalette{input: input}
+	c.filter()
+	return c
+}
+
+// filter refreshes the list of pages matching the current search
+func (c *commandPalette) filter() {
+	c.matches = nil
+	query := strings.ToLower(c.input.Value())
+	for _, p := range mainModel.pages {
+		if !canJumpTo(p.ID()) {
+			continue
+		}
+		if fuzzyMatch(strings.ToLower(p.Title()), query) || fuzzyMatch(strings.ToLower(p.ID()), query) {
+			c.matches = append(c.matches, p)
+		}
+	}
+	if c.cursor >= len(c.matches) {
+		c.cursor = max(len(c.matches)-1, 0)
+	}
+}
+
+// canJumpTo reports whether the palette may go to the page. The install only starts from the summary, and
+// the pages after the disk wipe confirmation need the wipe confirmed first, so jumping can't skip it.
+func canJumpTo(pageID string) bool {
+	switch pageID {
+	case "install_process":
+		return false
+	case "welcome", "resume", "disk_selection":
+		return true
+	case "confirmation":
+		return mainModel.disk != "" && !mainModel.rescue
+	}
+	if mainModel.rescue {
+		return mainModel.disk != ""
+	}
+	return pageID != "rescue" && mainModel.disk != "" && mainModel.wipeConfirmed
+}
+
+// fuzzyMatch reports whether all characters in query appear in s in the same order
+func fuzzyMatch(s, query string) bool {
+	for _, r := range query {
+		i := strings.IndexRune(s, r)
+		if i == -1 {
+			return false
+		}
+		s = s[i+len(string(r)):]
+	}
+	return true
+}
+
+// Update handles a key while the palette is open. It returns the ID of the page to go to once one is picked
+// and whether the palette should be closed.
+func (c *commandPalette) Update(msg tea.KeyMsg) (pageID string, done bool, cmd tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		return "", true, nil
+	case "up", "ctrl+p":
+		if c.cursor > 0 {
+			c.cursor--
+		}
+		return "", false, nil
+	case "down", "ctrl+n":
+		if c.cursor < len(c.matches)-1 {
+			c.cursor++
+		}
+		return "", false, nil
+	case "enter":
+		if c.cursor < len(c.matches) {
+			return c.matches[c.cursor].ID(), true, nil
+		}
+		return "", false, nil
+	}
+	c.input, cmd = c.input.Update(msg)
+	c.filter()
+	return "", false, cmd
+}
+
+func (c *commandPalette) View() string {
+	s := "Go to page\n\n"
+	s += c.input.View() + "\n\n"
+	if len(c.matches) == 0 {
+		return s + "No matching pages\n"
+	}
+	for i, p := range c.matches {
+		s += listItem(i, c.cursor, len(c.matches), fmt.Sprintf("%s (%s)", p.Title(), p.ID())) + "\n"
+	}
+	return s
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// systemPartitionsMiB is roughly what the partitions kairos-agent creates besides the persistent one take
+// by default: EFI, OEM, state and recovery
+const systemPartitionsMiB = 64 + 64 + 8192 + 8192
+
+// parseSizeMiB parses a size like 20480, 20480M or 20G into MiB. Plain numbers are MiB, as in the install config.
+func parseSizeMiB(value string) (uint64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	unit := uint64(1)
+	for _, u := range []struct {
+		suffix string
+		mib    uint64
+	}{{"GIB", 1024}, {"GB", 1024}, {"G", 1024}, {"MIB", 1}, {"MB", 1}, {"M", 1}} {
+		if strings.HasSuffix(value, u.suffix) {
+			value, unit = strings.TrimSpace(strings.TrimSuffix(value, u.suffix)), u.mib
+			break
+		}
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("%q is not a size, use e.g. 20480M or 20G", value)
+	}
+	return n * unit, nil
+}
+
+// availableMiB returns the space left on the disk for the persistent partition, 0 if the disk size is not known
+func availableMiB(disk diskStruct) uint64 {
+	total := disk.sizeBytes / (1024 * 1024)
+	if total <= systemPartitionsMiB {
+		return 0
+	}
+	return total - systemPartitionsMiB
+}
+
+// Persistent Size Page, sets the size of the persistent partition, using the rest of the disk by default
+type persistentSizePage struct {
+	input textinput.Model
+	err   error
+}
+
+func newPersistentSizePage() *persistentSizePage {
+	input := textinput.New()
+	input.Placeholder = "use remaining space"
+	input.Width = 20
+	input.Focus()
+	return &persistentSizePage{input: input}
+}
+
+func (p *persistentSizePage) Init() tea.Cmd {
+	p.input.SetValue("")
+	if mainModel.persistentSize > 0 {
+		p.input.SetValue(fmt.Sprintf("%dM", mainModel.persistentSize))
+	}
+	p.err = nil
+	return textinput.Blink
+}
+
+func (p *persistentSizePage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			var size uint64
+			if value := strings.TrimSpace(p.input.Value()); value != "" {
+				if size, p.err = parseSizeMiB(value); p.err != nil {
+					return p, nil
+				}
+			}
+			mainModel.log.Printf("Setting the persistent partition size to %d MiB", size)
+			mainModel.persistentSize = size
+			return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+		}
+	}
+
+	p.input, cmd = p.input.Update(msg)
+	return p, cmd
+}
+
+func (p *persistentSizePage) View() string {
+	s := "Persistent Partition Size\n\n"
+	s += "Size of the persistent partition, holding the data of the system:\n"
+	s += p.input.View() + "\n\n"
+	s += "Leave empty to use the remaining space of the disk.\n"
+
+	available := availableMiB(mainModel.selectedDisk)
+	if available > 0 {
+		s += fmt.Sprintf("About %s are left on %s after the system partitions.\n", humanSize(available*1024*1024), mainModel.disk)
+	}
+	if size, err := parseSizeMiB(p.input.Value()); err == nil && available > 0 && size > available {
+		s += "\n" + lipgloss.NewStyle().Foreground(kairosHighlight2).Render(
+			fmt.Sprintf("%s That is more than the space left on the disk, the install will fail.", glyphs.Warning),
+		) + "\n"
+	}
+	if p.err != nil {
+		s += "\n" + lipgloss.NewStyle().Foreground(kairosHighlight2).Render("Invalid size: "+p.err.Error()) + "\n"
+	}
+
+	return s
+}
+
+func (p *persistentSizePage) Title() string {
+	return "Persistent Size"
+}
+
+func (p *persistentSizePage) Help() string {
+	return "enter: save • esc: cancel"
+}
+
+func (p *persistentSizePage) ID() string { return "persistent_size" }
+
+func (p *persistentSizePage) BackTarget() string { return "customization" }
+
+func (p *persistentSizePage) TakingTextInput() bool { return true }
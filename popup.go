@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// confirmPopup renders a y/n question in a bordered box, centered on the screen
+func confirmPopup(question string) string {
+	popupStyle := lipgloss.NewStyle().
+		Border(glyphs.Border).
+		BorderForeground(kairosAccent).
+		Background(kairosBg).
+		Padding(1, 2).
+		Align(lipgloss.Center)
+	popup := popupStyle.Render(fmt.Sprintf("%s %s (y/n)", glyphs.Warning, question))
+	popupHeight := mainModel.height
+	if mainModel.inline {
+		// Inline output grows with its content, so don't pad the popup to the full screen
+		popupHeight = lipgloss.Height(popup)
+	}
+	return lipgloss.Place(mainModel.width, popupHeight, lipgloss.Center, lipgloss.Center, popup)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// profileDir is where profiles saved from the summary page are written
+var profileDir = "."
+
+// profile is a reusable set of answers, saved from the summary page and loaded with --profile
+type profile struct {
+	Disk           string         `yaml:"disk,omitempty"` // Stable name of the disk, so it matches across reboots
+	Users          []userAccount  `yaml:"users,omitempty"`
+	KernelArgs     string         `yaml:"kernel_args,omitempty"`
+	Filesystem     string         `yaml:"filesystem,omitempty"`
+	PersistentSize uint64         `yaml:"persistent_size,omitempty"` // MiB
+	ImageMirror    string         `yaml:"image_mirror,omitempty"`
+	CustomSteps    []stageStep    `yaml:"custom_steps,omitempty"`
+	ExtraFields    map[string]any `yaml:"extra_fields,omitempty"`
+	// LegacySSHKeys are the keys of profiles saved before keys were kept per user, only read to move
+	// them to the first user
+	LegacySSHKeys []string `yaml:"ssh_keys,omitempty"`
+}
+
+// currentProfile captures the answers from the model, leaving the secrets out unless asked to
+func currentProfile(m model, withSecrets bool) profile {
+	p := profile{
+		Disk:           m.selectedDisk.stableName(),
+		KernelArgs:     m.kernelArgs,
+		Filesystem:     m.filesystem,
+		PersistentSize: m.persistentSize,
+		ImageMirror:    m.imageMirror,
+		CustomSteps:    m.customSteps,
+		ExtraFields:    map[string]any{},
+	}
+	if p.Disk == "" {
+		p.Disk = m.disk
+	}
+	for _, u := range m.users {
+		if !withSecrets {
+			u.Password = ""
+		}
+		p.Users = append(p.Users, u)
+	}
+	for key, value := range m.extraFields {
+		if !withSecrets && isSecretSection(key) {
+			continue
+		}
+		p.ExtraFields[key] = value
+	}
+	return p
+}
+
+// profilePath returns the file a profile with the given name is saved to
+func profilePath(name string) string {
+	return filepath.Join(profileDir, name+".yaml")
+}
+
+// saveProfile writes the current answers as a profile with the given name, returning where it was saved
+func saveProfile(name string, withSecrets bool) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsRune(name, filepath.Separator) {
+		return "", fmt.Errorf("invalid profile name %q", name)
+	}
+	data, err := yaml.Marshal(currentProfile(mainModel, withSecrets))
+	if err != nil {
+		return "", err
+	}
+	path := profilePath(name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// loadProfile reads a profile, given either as a path or as the name it was saved with
+func loadProfile(nameOrPath string) (profile, error) {
+	var p profile
+	path := nameOrPath
+	if _, err := os.Stat(path); err != nil {
+		path = profilePath(nameOrPath)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return p, err
+	}
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return p, fmt.Errorf("parsing profile %s: %w", path, err)
+	}
+	var moved bool
+	if p.Users, moved = withLegacySSHKeys(p.Users, p.LegacySSHKeys); !moved {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring the ssh_keys of profile %s, it has no user to give them to\n", path)
+	}
+	p.LegacySSHKeys = nil
+	return p, nil
+}
+
+// withLegacySSHKeys gives the keys from the top level ssh_keys of older profiles and sessions, when there
+// was a single list of keys, to the first user. It reports false if there were keys but no user to take them.
+func withLegacySSHKeys(users []userAccount, keys []string) ([]userAccount, bool) {
+	if len(keys) == 0 {
+		return users, true
+	}
+	if len(users) == 0 {
+		mainModel.log.Printf("Ignoring %d SSH keys from the older top level ssh_keys, there is no user to give them to", len(keys))
+		return users, false
+	}
+	users = slices.Clone(users)
+	first := &users[0]
+	first.SSHKeys = slices.Clone(first.SSHKeys)
+	for _, key := range keys {
+		if !slices.Contains(first.SSHKeys, key) {
+			first.SSHKeys = append(first.SSHKeys, key)
+		}
+	}
+	mainModel.log.Printf("Moved %d SSH keys from the older top level ssh_keys to user %s", len(keys), first.Name)
+	return users, true
+}
+
+// preselectDisk puts the cursor of the disk selection on the given disk once scanned
+func preselectDisk(device string) {
+	mainModel.preselectedDisk = device
+	for _, page := range mainModel.pages {
+		if dp, ok := page.(*diskSelectionPage); ok {
+			dp.preselect = device
+		}
+	}
+}
+
+// applyProfile fills the model and the pages with the answers from the profile. The disk is only
+// preselected, so it is still checked against the disks found and its wipe confirmed.
+func applyProfile(p profile) {
+	if p.Disk != "" {
+		preselectDisk(p.Disk)
+	}
+	if len(p.Users) > 0 {
+		restoreUsers(p.Users)
+	}
+	mainModel.kernelArgs = p.KernelArgs
+	if err := validateFilesystem(p.Filesystem); err != nil {
+		mainModel.log.Printf("Ignoring the filesystem of the profile: %v", err)
+	} else {
+		mainModel.filesystem = p.Filesystem
+	}
+	mainModel.persistentSize = p.PersistentSize
+	if err := validateImageMirror(p.ImageMirror); err != nil {
+		mainModel.log.Printf("Ignoring the image mirror of the profile: %v", err)
+	} else {
+		mainModel.imageMirror = p.ImageMirror
+	}
+	for _, step := range p.CustomSteps {
+		if err := validateStageName(step.Stage); err != nil {
+			mainModel.log.Printf("Ignoring custom step %q of the profile: %v", step.Name, err)
+			continue
+		}
+		mainModel.customSteps = append(mainModel.customSteps, step)
+	}
+	for key, value := range p.ExtraFields {
+		if mainModel.extraFields == nil {
+			mainModel.extraFields = map[string]any{}
+		}
+		mainModel.extraFields[key] = value
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadProfileLegacySSHKeys(t *testing.T) {
+	oldModel := mainModel
+	t.Cleanup(func() { mainModel = oldModel })
+	mainModel = model{log: log.New(io.Discard, "", 0)}
+
+	path := filepath.Join(t.TempDir(), "old.yaml")
+	legacy := `users:
+  - name: kairos
+    ssh_keys: [github:kairos]
+  - name: ops
+ssh_keys: [github:kairos, "ssh-ed25519 AAAA old"]
+`
+	if err := os.WriteFile(path, []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := loadProfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"github:kairos", "ssh-ed25519 AAAA old"}; !slices.Equal(p.Users[0].SSHKeys, want) {
+		t.Errorf("first user got keys %q, want %q", p.Users[0].SSHKeys, want)
+	}
+	if len(p.Users[1].SSHKeys) > 0 {
+		t.Errorf("second user got keys %q", p.Users[1].SSHKeys)
+	}
+	if len(p.LegacySSHKeys) > 0 {
+		t.Errorf("legacy keys kept around: %q", p.LegacySSHKeys)
+	}
+}
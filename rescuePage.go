@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Labels of the partitions of an existing Kairos installation the rescue works with
+const (
+	oemPartitionLabel   = "COS_OEM"
+	statePartitionLabel = "COS_STATE"
+)
+
+// rescueConfigFile is the name of the config written to the OEM partition when re-running the configuration
+const rescueConfigFile = "99_rescue.yaml"
+
+// rescueDoneMsg is sent when a rescue action finishes
+type rescueDoneMsg struct {
+	result string
+	err    error
+}
+
+// partitionDevice returns the device of the partition of the disk with the given partition or filesystem label
+func (d diskStruct) partitionDevice(label string) (string, bool) {
+	for _, part := range d.partitions {
+		if strings.EqualFold(part.FilesystemLabel, label) || strings.EqualFold(part.Label, label) {
+			return filepath.Join("/dev", part.Name), true
+		}
+	}
+	return "", false
+}
+
+// withMountedPartition mounts the partition with the given label of the disk, runs fn with the mountpoint and unmounts it
+func withMountedPartition(disk diskStruct, label string, readOnly bool, fn func(dir string) error) error {
+	device, ok := disk.partitionDevice(label)
+	if !ok {
+		return fmt.Errorf("no %s partition on %s", label, disk.name)
+	}
+	dir, err := os.MkdirTemp("", "kairos-rescue-"+strings.ToLower(label))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dir)
+	args := []string{device, dir}
+	if readOnly {
+		args = append([]string{"-o", "ro"}, args...)
+	}
+	if out, err := exec.Command("mount", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("mounting %s: %v: %s", device, err, strings.TrimSpace(string(out)))
+	}
+	defer func() {
+		if out, err := exec.Command("umount", dir).CombinedOutput(); err != nil {
+			mainModel.log.Printf("Error unmounting %s: %v: %s", dir, err, out)
+		}
+	}()
+	return fn(dir)
+}
+
+// collectDiagnostics gathers the block device layout, kernel log, installer log and the configuration of the
+// existing installation into a directory, without changing anything on the disk
+func collectDiagnostics(disk diskStruct) (string, error) {
+	dir := filepath.Join(os.TempDir(), "kairos-rescue-"+time.Now().Format("20060102-150405"))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	for name, command := range map[string][]string{
+		"lsblk.txt": {"lsblk", "-f"},
+		"blkid.txt": {"blkid"},
+		"dmesg.txt": {"dmesg"},
+	} {
+		out, err := exec.Command(command[0], command[1:]...).CombinedOutput()
+		if err != nil {
+			out = append(out, []byte(fmt.Sprintf("\n%s failed: %v\n", command[0], err))...)
+		}
+		_ = os.WriteFile(filepath.Join(dir, name), out, 0600)
+	}
+	if log, err := os.ReadFile("/tmp/kairos-installer.log"); err == nil {
+		_ = os.WriteFile(filepath.Join(dir, "kairos-installer.log"), log, 0600)
+	}
+
+	// The OEM partition holds the configuration of the installed system
+	err := withMountedPartition(disk, oemPartitionLabel, true, func(mnt string) error {
+		return filepath.WalkDir(mnt, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(mnt, path)
+			return os.WriteFile(filepath.Join(dir, "oem-"+strings.ReplaceAll(rel, "/", "_")), data, 0600)
+		})
+	})
+	if err != nil {
+		mainModel.log.Printf("Error collecting the OEM configuration: %v", err)
+	}
+	// The state partition holds the system images, list them to see what is installed
+	err = withMountedPartition(disk, statePartitionLabel, true, func(mnt string) error {
+		var listing strings.Builder
+		err := filepath.WalkDir(mnt, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(mnt, path)
+			if strings.Count(rel, string(filepath.Separator)) > 2 {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil // SkipDir on a file would skip the rest of its directory
+			}
+			if info, err := d.Info(); err == nil {
+				fmt.Fprintf(&listing, "%s %12d %s\n", info.Mode(), info.Size(), rel)
+			}
+			return nil
+		})
+		_ = os.WriteFile(filepath.Join(dir, "state-listing.txt"), []byte(listing.String()), 0600)
+		return err
+	})
+	if err != nil {
+		mainModel.log.Printf("Error listing the state partition: %v", err)
+	}
+	return dir, nil
+}
+
+// rescueConfig returns the configuration from the answers, to be applied to an existing installation.
+// The install section is left out, there is nothing to install.
+func rescueConfig() *InstallConfig {
+	cfg := NewInstallConfig(mainModel)
+	cfg.Install = nil
+	return cfg
+}
+
+// applyRescueConfig writes cfg to the OEM partition of the existing installation, so it is applied on its next boot.
+// It runs in the background, so cfg has to be built beforehand from the Update loop.
+func applyRescueConfig(disk diskStruct, cfg *InstallConfig) (string, error) {
+	var path string
+	err := withMountedPartition(disk, oemPartitionLabel, false, func(mnt string) error {
+		path = filepath.Join(mnt, rescueConfigFile)
+		return cfg.WriteYAML(path)
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s written to the %s partition of %s", rescueConfigFile, oemPartitionLabel, disk.name), nil
+}
+
+// Rescue Page, works on an existing installation instead of installing a new one
+type rescuePage struct {
+	cursor  int
+	options []string
+	running string // Action in progress, if any
+	result  string
+	err     error
+	spinner spinner.Model
+}
+
+func newRescuePage() *rescuePage {
+	s := spinner.New(spinner.WithSpinner(glyphs.Spinner))
+	s.Style = lipgloss.NewStyle().Foreground(kairosAccent)
+	return &rescuePage{
+		options: []string{
+			"Collect diagnostics",
+			"Re-run configuration",
+			"Pick another disk",
+		},
+		spinner: s,
+	}
+}
+
+func (p *rescuePage) Init() tea.Cmd {
+	return nil
+}
+
+// start runs a rescue action in the background, showing the spinner meanwhile
+func (p *rescuePage) start(action string, fn func(diskStruct) (string, error)) tea.Cmd {
+	p.running = action
+	p.result = ""
+	p.err = nil
+	disk := mainModel.selectedDisk
+	mainModel.log.Printf("Rescue: %s on %s", action, disk.name)
+	return tea.Batch(p.spinner.Tick, func() tea.Msg {
+		result, err := fn(disk)
+		return rescueDoneMsg{result: result, err: err}
+	})
+}
+
+func (p *rescuePage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		if p.running == "" {
+			return p, nil
+		}
+		var cmd tea.Cmd
+		p.spinner, cmd = p.spinner.Update(msg)
+		return p, cmd
+	case rescueDoneMsg:
+		p.running = ""
+		p.result = msg.result
+		p.err = msg.err
+		if msg.err != nil {
+			mainModel.log.Printf("Rescue failed: %v", msg.err)
+		}
+		return p, nil
+	case tea.KeyMsg:
+		if p.running != "" {
+			return p, nil
+		}
+		switch msg.String() {
+		case "up", "k":
+			if p.cursor > 0 {
+				p.cursor--
+			}
+		case "down", "j":
+			if p.cursor < len(p.options)-1 {
+				p.cursor++
+			}
+		case "enter":
+			switch p.cursor {
+			case 0:
+				return p, p.start("Collecting diagnostics", func(disk diskStruct) (string, error) {
+					dir, err := collectDiagnostics(disk)
+					return fmt.Sprintf("Diagnostics saved to %s", dir), err
+				})
+			case 1:
+				// Answer the customization questions, the summary applies them
+				return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+			default:
+				return p, func() tea.Msg { return GoToPageMsg{PageID: "disk_selection"} }
+			}
+		}
+	}
+	return p, nil
+}
+
+func (p *rescuePage) View() string {
+	s := fmt.Sprintf("Rescue the installation on %s\n\n", mainModel.selectedDisk.name)
+	if labels := mainModel.selectedDisk.kairosLabels(); len(labels) > 0 {
+		s += fmt.Sprintf("Found Kairos partitions: %s\n\n", strings.Join(labels, ", "))
+	}
+	s += "Nothing is wiped, the existing system is kept.\n\n"
+
+	for i, option := range p.options {
+		s += listItem(i, p.cursor, len(p.options), option) + "\n"
+	}
+
+	switch {
+	case p.running != "":
+		s += "\n" + p.spinner.View() + " " + p.running + "..."
+	case p.err != nil:
+		s += "\n" + lipgloss.NewStyle().Foreground(kairosHighlight2).Render(fmt.Sprintf("%s %v", glyphs.Warning, p.err))
+	case p.result != "":
+		s += "\n" + lipgloss.NewStyle().Foreground(kairosAccent).Render(fmt.Sprintf("%s %s", glyphs.Check, p.result))
+	}
+	return s
+}
+
+func (p *rescuePage) Title() string {
+	return "Rescue"
+}
+
+func (p *rescuePage) Help() string {
+	return genericNavigationHelp
+}
+
+func (p *rescuePage) ID() string { return "rescue" }
+
+func (p *rescuePage) BackTarget() string { return "disk_selection" }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io"
+	"log"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestApplyToRescueBuildsConfigUpfront(t *testing.T) {
+	oldModel := mainModel
+	t.Cleanup(func() { mainModel = oldModel })
+	rp := newRescuePage()
+	mainModel = model{
+		log:          log.New(io.Discard, "", 0),
+		pages:        []Page{rp},
+		selectedDisk: diskStruct{name: "/dev/test"}, // No OEM partition, nothing gets mounted
+		extraFields:  map[string]any{"hostname": "rescued"},
+	}
+
+	cmd := (&summaryPage{}).applyToRescue()
+	done := make(chan []tea.Msg)
+	go func() {
+		done <- runCmd(cmd)
+	}()
+	// The Update loop keeps going while the configuration is applied in the background
+	for i := 0; i < 100; i++ {
+		mainModel.extraFields["hostname"] = "changed"
+		mainModel.disk = "/dev/changed"
+	}
+
+	var result *rescueDoneMsg
+	for _, msg := range <-done {
+		if msg, ok := msg.(rescueDoneMsg); ok {
+			result = &msg
+		}
+	}
+	if result == nil || result.err == nil {
+		t.Fatalf("applying to a disk without an OEM partition gave %+v, want an error", result)
+	}
+}
+
+// runCmd runs cmd and the commands of the batches it returns one after the other, returning their messages
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	batch, ok := msg.(tea.BatchMsg)
+	if !ok {
+		return []tea.Msg{msg}
+	}
+	var msgs []tea.Msg
+	for _, cmd := range batch {
+		msgs = append(msgs, runCmd(cmd)...)
+	}
+	return msgs
+}
+
+func TestRescueConfigKeepsHostname(t *testing.T) {
+	oldModel := mainModel
+	t.Cleanup(func() { mainModel = oldModel })
+	mainModel = model{log: log.New(io.Discard, "", 0), rescue: true, defaultHostname: "kairos-generated"}
+
+	if hostname, ok := rescueConfig().ExtraFields[hostnameSection]; ok {
+		t.Errorf("rescue config renames the installation to %v", hostname)
+	}
+
+	mainModel.extraFields = map[string]any{hostnameSection: "rescued"}
+	if hostname := rescueConfig().ExtraFields[hostnameSection]; hostname != "rescued" {
+		t.Errorf("rescue config sets hostname %v, want the one given", hostname)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// resumeField is a previously given answer, which the user can keep or change
+type resumeField struct {
+	key   string // disk, users, kernel_args, filesystem, persistent_size, image_mirror, custom_stages or the extraFields key
+	label string
+	value string
+	keep  bool
+}
+
+// Resume Page, reviews the answers from a saved session before continuing with them
+type resumePage struct {
+	noBackTarget
+
+	cursor  int
+	fields  []resumeField
+	session session
+}
+
+func newResumePage(s session) *resumePage {
+	p := &resumePage{session: s}
+	if s.Disk != "" {
+		p.fields = append(p.fields, resumeField{key: "disk", label: "Disk", value: s.Disk, keep: true})
+	}
+	if len(s.Users) > 0 {
+		p.fields = append(p.fields, resumeField{key: "users", label: "Users", value: strings.Join(userNames(s.Users), ", "), keep: true})
+	}
+	if s.KernelArgs != "" {
+		p.fields = append(p.fields, resumeField{key: "kernel_args", label: "Kernel Arguments", value: truncate(s.KernelArgs, previewLength), keep: true})
+	}
+	if s.Filesystem != "" {
+		p.fields = append(p.fields, resumeField{key: "filesystem", label: "Filesystem", value: s.Filesystem, keep: true})
+	}
+	if s.PersistentSize > 0 {
+		p.fields = append(p.fields, resumeField{key: "persistent_size", label: "Persistent Size", value: humanSize(s.PersistentSize * 1024 * 1024), keep: true})
+	}
+	if s.ImageMirror != "" {
+		p.fields = append(p.fields, resumeField{key: "image_mirror", label: "Image Mirror", value: truncate(s.ImageMirror, previewLength), keep: true})
+	}
+	if len(s.CustomSteps) > 0 {
+		p.fields = append(p.fields, resumeField{key: "custom_stages", label: "Custom Stages", value: fmt.Sprintf("%d steps", len(s.CustomSteps)), keep: true})
+	}
+	keys := make([]string, 0, len(s.ExtraFields))
+	for key := range s.ExtraFields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := fmt.Sprintf("%v", s.ExtraFields[key])
+		if isSecretSection(key) {
+			value = maskSecret(value)
+		}
+		p.fields = append(p.fields, resumeField{key: key, label: key, value: truncate(value, previewLength), keep: true})
+	}
+	return p
+}
+
+func (p *resumePage) Init() tea.Cmd {
+	return nil
+}
+
+func (p *resumePage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if p.cursor > 0 {
+				p.cursor--
+			}
+		case "down", "j":
+			// +2 for the "Continue" and "Start over" options
+			if p.cursor < len(p.fields)+1 {
+				p.cursor++
+			}
+		case " ":
+			if p.cursor < len(p.fields) {
+				p.fields[p.cursor].keep = !p.fields[p.cursor].keep
+			}
+		case "enter":
+			switch {
+			case p.cursor < len(p.fields):
+				p.fields[p.cursor].keep = !p.fields[p.cursor].keep
+			case p.cursor == len(p.fields):
+				return p, p.resume()
+			default:
+				mainModel.log.Printf("Discarding saved session")
+				removeSession()
+				return p, func() tea.Msg { return GoToPageMsg{PageID: firstPageAfter(p.ID())} }
+			}
+		}
+	}
+	return p, nil
+}
+
+// resume restores the kept answers into the model and the pages holding them
+func (p *resumePage) resume() tea.Cmd {
+	next := "disk_selection"
+	for _, f := range p.fields {
+		if !f.keep {
+			continue
+		}
+		switch f.key {
+		case "disk":
+			disk, ok := resumedDisk(p.session.Disk)
+			if !ok {
+				mainModel.log.Printf("Disk %s from the session not found anymore, picking it again", p.session.Disk)
+				continue
+			}
+			mainModel.disk = p.session.Disk
+			mainModel.selectedDisk = disk
+			// Still confirm wiping the disk
+			next = "confirmation"
+		case "users":
+			restoreUsers(p.session.Users)
+		case "kernel_args":
+			mainModel.kernelArgs = p.session.KernelArgs
+		case "filesystem":
+			mainModel.filesystem = p.session.Filesystem
+		case "persistent_size":
+			mainModel.persistentSize = p.session.PersistentSize
+		case "image_mirror":
+			mainModel.imageMirror = p.session.ImageMirror
+		case "custom_stages":
+			mainModel.customSteps = p.session.CustomSteps
+		default:
+			if mainModel.extraFields == nil {
+				mainModel.extraFields = map[string]any{}
+			}
+			mainModel.extraFields[f.key] = p.session.ExtraFields[f.key]
+		}
+	}
+	mainModel.log.Printf("Resuming saved session, continuing at %s", next)
+	return func() tea.Msg { return GoToPageMsg{PageID: next} }
+}
+
+// resumedDisk looks up the disk of the session among the disks probed at startup, the disk page hasn't
+// scanned yet. The disk page is given the list with the disk under the cursor, going back to it from the
+// confirmation shows the resumed disk instead of the default pick.
+func resumedDisk(device string) (diskStruct, bool) {
+	disks, err := cachedDisks(false)
+	if err != nil {
+		mainModel.log.Printf("Error listing disks to resume the session: %v", err)
+		return diskStruct{}, false
+	}
+	i, found := findDisk(disks, device)
+	if !found {
+		return diskStruct{}, false
+	}
+	for _, page := range mainModel.pages {
+		if dp, ok := page.(*diskSelectionPage); ok {
+			dp.disks = disks
+			dp.cursor = i
+			dp.preselectApplied = true
+		}
+	}
+	return disks[i], true
+}
+
+func (p *resumePage) View() string {
+	s := "A previous installer session was found.\n"
+	s += "Review the answers given then, and choose which ones to keep:\n\n"
+
+	total := len(p.fields) + 2
+	for i, f := range p.fields {
+		action := "keep  "
+		if !f.keep {
+			action = "change"
+		}
+		s += listItem(i, p.cursor, total, fmt.Sprintf("[%s] %s: %s", action, f.label, f.value)) + "\n"
+	}
+	s += "\n"
+	s += listItem(len(p.fields), p.cursor, total, "Continue with the kept answers") + "\n"
+	s += listItem(len(p.fields)+1, p.cursor, total, "Start over") + "\n"
+
+	return strings.TrimSuffix(s, "\n")
+}
+
+func (p *resumePage) Title() string {
+	return "Resume Session"
+}
+
+func (p *resumePage) Help() string {
+	return "↑/k: up • ↓/j: down • space/enter: keep or change • enter: select"
+}
+
+func (p *resumePage) ID() string { return "resume" }
+
+// firstPageAfter returns the ID of the page following the given one in mainModel.pages
+func firstPageAfter(pageID string) string {
+	for i, p := range mainModel.pages {
+		if p.ID() == pageID && i+1 < len(mainModel.pages) {
+			return mainModel.pages[i+1].ID()
+		}
+	}
+	return "disk_selection"
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io"
+	"log"
+	"testing"
+)
+
+func TestResumeDiskFromColdStart(t *testing.T) {
+	oldModel := mainModel
+	diskCache.Lock()
+	oldDisks, oldValid := diskCache.disks, diskCache.valid
+	// As left by the splash probe
+	diskCache.disks = []diskStruct{{name: "/dev/test-a", sizeBytes: 1 << 40}, {name: "/dev/test-b", sizeBytes: 1 << 30}}
+	diskCache.valid = true
+	diskCache.Unlock()
+	t.Cleanup(func() {
+		mainModel = oldModel
+		diskCache.Lock()
+		diskCache.disks, diskCache.valid = oldDisks, oldValid
+		diskCache.Unlock()
+	})
+
+	mainModel = model{log: log.New(io.Discard, "", 0)}
+	rp := newResumePage(session{Disk: "/dev/test-b"})
+	dp := newDiskSelectionPage() // Never shown, it hasn't scanned
+	mainModel.pages = []Page{rp, dp}
+	mainModel.currentPageID = rp.ID()
+
+	msgs := runCmd(rp.resume())
+	if len(msgs) != 1 || msgs[0] != (GoToPageMsg{PageID: "confirmation"}) {
+		t.Errorf("resume went to %#v, want the confirmation", msgs)
+	}
+	if mainModel.disk != "/dev/test-b" || mainModel.selectedDisk.name != "/dev/test-b" {
+		t.Errorf("resumed disk %q selected %q, want /dev/test-b", mainModel.disk, mainModel.selectedDisk.name)
+	}
+
+	// Going back to the disk page still shows the resumed disk once it has scanned
+	dp.Init()
+	dp.Update(disksScannedMsg{disks: diskCache.disks})
+	if dp.cursor != 1 {
+		t.Errorf("disk page cursor on %d after resuming, want 1", dp.cursor)
+	}
+}
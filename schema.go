@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaKind is the type a config value must have
+type schemaKind int
+
+const (
+	schemaAny schemaKind = iota
+	schemaString
+	schemaBool
+	schemaInt
+	schemaMap
+	schemaList
+)
+
+func (k schemaKind) String() string {
+	switch k {
+	case schemaString:
+		return "a string"
+	case schemaBool:
+		return "true or false"
+	case schemaInt:
+		return "a number"
+	case schemaMap:
+		return "a map"
+	case schemaList:
+		return "a list"
+	}
+	return "anything"
+}
+
+// schemaNode describes a value of the cloud config. Maps either list their known keys in fields, or take
+// any key with values as described by values. Lists describe their items with values.
+type schemaNode struct {
+	kind   schemaKind
+	fields map[string]*schemaNode
+	values *schemaNode
+}
+
+var (
+	anyValue    = &schemaNode{kind: schemaAny}
+	stringValue = &schemaNode{kind: schemaString}
+	boolValue   = &schemaNode{kind: schemaBool}
+	intValue    = &schemaNode{kind: schemaInt}
+	stringList  = &schemaNode{kind: schemaList, values: stringValue}
+	anyMap      = &schemaNode{kind: schemaMap, values: anyValue}
+)
+
+// partitionSchema is a partition in install.partitions
+var partitionSchema = &schemaNode{kind: schemaMap, fields: map[string]*schemaNode{
+	"size": intValue, "fs": stringValue, "label": stringValue, "name": stringValue,
+}}
+
+// stepSchema is a step of a stage, as run by yip
+var stepSchema = &schemaNode{kind: schemaMap, fields: map[string]*schemaNode{
+	"name": stringValue, "if": stringValue, "only_os": stringValue, "only_os_version": stringValue,
+	"hostname": stringValue, "commands": stringList, "modules": stringList,
+	"environment": anyMap, "environment_file": stringValue, "sysctl": anyMap,
+	"dns": anyMap, "ssh_authorized_keys": anyMap, "systemctl": anyMap, "timesyncd": anyMap,
+	"directories": {kind: schemaList, values: anyMap}, "downloads": {kind: schemaList, values: anyMap},
+	"entities": {kind: schemaList, values: anyMap}, "delete_entities": {kind: schemaList, values: anyMap},
+	"layout": anyMap, "datasource": anyMap, "git": anyMap, "packages": anyMap, "systemd_firstboot": anyMap,
+	"users": {kind: schemaMap, values: &schemaNode{kind: schemaMap, fields: map[string]*schemaNode{
+		"name": stringValue, "passwd": stringValue, "gecos": stringValue, "homedir": stringValue,
+		"shell": stringValue, "primary_group": stringValue, "uid": stringValue, "groups": stringList,
+		"ssh_authorized_keys": stringList, "lock_passwd": boolValue, "no_create_home": boolValue, "system": boolValue,
+	}}},
+	"files": {kind: schemaList, values: &schemaNode{kind: schemaMap, fields: map[string]*schemaNode{
+		"path": stringValue, "content": stringValue, "encoding": stringValue, "owner_string": stringValue,
+		"permissions": intValue, "owner": intValue, "group": intValue,
+	}}},
+}}
+
+// configSchema is the part of the Kairos cloud config the installer knows about. Keys it doesn't know are
+// flagged, as they are usually typos, but the agent gets them anyway.
+var configSchema = &schemaNode{kind: schemaMap, fields: map[string]*schemaNode{
+	"install": {kind: schemaMap, fields: map[string]*schemaNode{
+		"device": stringValue, "auto": boolValue, "reboot": boolValue, "poweroff": boolValue,
+		"nousers": boolValue, "source": stringValue, "image": stringValue, "force": boolValue,
+		"grub_options": anyMap, "bind_mounts": stringList, "ephemeral_mounts": stringList,
+		"encrypted_partitions": stringList, "extra-dirs-rootfs": stringList, "bundles": {kind: schemaList, values: anyMap},
+		"system": anyMap, "recovery-system": anyMap, "passive": anyMap,
+		"partitions": {kind: schemaMap, fields: map[string]*schemaNode{
+			"oem": partitionSchema, "recovery": partitionSchema, "state": partitionSchema, "persistent": partitionSchema,
+		}},
+	}},
+	"stages": {kind: schemaMap, values: &schemaNode{kind: schemaList, values: stepSchema}},
+
+	"hostname": stringValue, "name": stringValue, "config_url": stringValue, "strict": boolValue,
+	"fail_on_bundles_errors": boolValue, "debug": boolValue, "options": anyMap,
+	"upgrade": anyMap, "reset": anyMap, "bundles": {kind: schemaList, values: anyMap},
+	"k3s": anyMap, "k3s-agent": anyMap, "k0s": anyMap, "k0s-worker": anyMap, "p2p": anyMap, "kubevip": anyMap,
+}}
+
+// schemaViolation is a key of the config that doesn't follow the schema
+type schemaViolation struct {
+	path    string // Dotted path to the key, with list indexes, e.g. stages.network[0].users
+	problem string
+}
+
+func (v schemaViolation) String() string {
+	return v.path + ": " + v.problem
+}
+
+// checkConfig validates the assembled config against configSchema, returning the violations sorted by path
+func checkConfig(cfg *InstallConfig) []schemaViolation {
+	// Go through YAML so the values have the types the agent will read, whatever the plugins put in
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return []schemaViolation{{path: "(config)", problem: err.Error()}}
+	}
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []schemaViolation{{path: "(config)", problem: err.Error()}}
+	}
+	var violations []schemaViolation
+	checkValue(configSchema, "", doc, &violations)
+	sort.Slice(violations, func(i, j int) bool { return violations[i].path < violations[j].path })
+	return violations
+}
+
+// checkValue validates value against the node, adding what doesn't fit under the given path
+func checkValue(node *schemaNode, path string, value any, violations *[]schemaViolation) {
+	if value == nil {
+		return // Left empty, the agent uses its default
+	}
+	fail := func(format string, args ...any) {
+		*violations = append(*violations, schemaViolation{path: path, problem: fmt.Sprintf(format, args...)})
+	}
+	switch node.kind {
+	case schemaString:
+		if _, ok := value.(string); !ok {
+			fail("expected %s, got %v", node.kind, value)
+		}
+	case schemaBool:
+		if _, ok := value.(bool); !ok {
+			fail("expected %s, got %v", node.kind, value)
+		}
+	case schemaInt:
+		if _, ok := value.(int); !ok {
+			fail("expected %s, got %v", node.kind, value)
+		}
+	case schemaList:
+		items, ok := value.([]any)
+		if !ok {
+			fail("expected %s", node.kind)
+			return
+		}
+		for i, item := range items {
+			checkValue(node.values, fmt.Sprintf("%s[%d]", path, i), item, violations)
+		}
+	case schemaMap:
+		fields, ok := value.(map[string]any)
+		if !ok {
+			fail("expected %s", node.kind)
+			return
+		}
+		for key, v := range fields {
+			child := node.values
+			if node.fields != nil {
+				if child, ok = node.fields[key]; !ok {
+					*violations = append(*violations, schemaViolation{path: joinKey(path, key), problem: "unknown key"})
+					continue
+				}
+			}
+			checkValue(child, joinKey(path, key), v, violations)
+		}
+	}
+}
+
+// joinKey adds a key to a dotted path
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
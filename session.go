@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// sessionPath is where the answers given so far are kept, so an interrupted run can be resumed. It holds
+// the passwords, so it lives in a directory only root can get into rather than in the shared /tmp.
+var sessionPath = "/run/kairos-installer/session.json"
+
+// session holds the answers given so far
+type session struct {
+	Disk           string         `json:"disk,omitempty"`
+	Users          []userAccount  `json:"users,omitempty"`
+	KernelArgs     string         `json:"kernel_args,omitempty"`
+	Filesystem     string         `json:"filesystem,omitempty"`
+	PersistentSize uint64         `json:"persistent_size,omitempty"`
+	ImageMirror    string         `json:"image_mirror,omitempty"`
+	CustomSteps    []stageStep    `json:"custom_steps,omitempty"`
+	ExtraFields    map[string]any `json:"extra_fields,omitempty"`
+	LegacySSHKeys  []string       `json:"ssh_keys,omitempty"` // From before keys were kept per user, see withLegacySSHKeys
+}
+
+// empty reports whether there is nothing worth resuming in the session
+func (s session) empty() bool {
+	return s.Disk == "" && len(s.Users) == 0 && s.KernelArgs == "" && s.Filesystem == "" && s.PersistentSize == 0 && s.ImageMirror == "" && len(s.CustomSteps) == 0 && len(s.ExtraFields) == 0
+}
+
+// currentSession captures the answers from the model
+func currentSession(m model) session {
+	return session{
+		Disk:           m.disk,
+		Users:          m.users,
+		KernelArgs:     m.kernelArgs,
+		Filesystem:     m.filesystem,
+		PersistentSize: m.persistentSize,
+		ImageMirror:    m.imageMirror,
+		CustomSteps:    m.customSteps,
+		ExtraFields:    m.extraFields,
+	}
+}
+
+// saveSession writes the answers given so far. It is best effort, failing to save only means the run can't be resumed.
+func saveSession() {
+	s := currentSession(mainModel)
+	if s.empty() {
+		return
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		mainModel.log.Printf("Error encoding session: %v", err)
+		return
+	}
+	// It holds the passwords, keep it private
+	if err := os.MkdirAll(filepath.Dir(sessionPath), 0700); err != nil {
+		mainModel.log.Printf("Error creating session directory: %v", err)
+		return
+	}
+	if err := os.WriteFile(sessionPath, data, 0600); err != nil {
+		mainModel.log.Printf("Error saving session to %s: %v", sessionPath, err)
+	}
+}
+
+// loadSession reads the answers saved by a previous run, if any
+func loadSession() (session, bool) {
+	var s session
+	data, err := os.ReadFile(sessionPath)
+	if err != nil {
+		return s, false
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		mainModel.log.Printf("Ignoring unreadable session at %s: %v", sessionPath, err)
+		return s, false
+	}
+	s.Users, _ = withLegacySSHKeys(s.Users, s.LegacySSHKeys)
+	s.LegacySSHKeys = nil
+	return s, !s.empty()
+}
+
+// removeSession drops the saved session, once it can't be resumed anymore
+func removeSession() {
+	if err := os.Remove(sessionPath); err != nil && !os.IsNotExist(err) {
+		mainModel.log.Printf("Error removing session %s: %v", sessionPath, err)
+	}
+}
+
+// restoreUsers sets the users in the model
+func restoreUsers(users []userAccount) {
+	mainModel.users = append([]userAccount{}, users...)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// shellKey leaves the installer for a shell, e.g. to prepare the disks by hand
+const shellKey = "!"
+
+// quitToShell leaves the TUI, restoring the terminal, so main can start a shell in place of the installer
+func quitToShell() tea.Cmd {
+	mainModel.log.Printf("Quitting to a shell")
+	mainModel.dropToShell = true
+	return tea.Quit
+}
+
+// loginShell returns the shell to drop to: $SHELL, or the first of bash and sh found
+func loginShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		if path, err := exec.LookPath(shell); err == nil {
+			return path
+		}
+	}
+	for _, shell := range []string{"bash", "sh"} {
+		if path, err := exec.LookPath(shell); err == nil {
+			return path
+		}
+	}
+	return "/bin/sh"
+}
+
+// execShell replaces the installer with a shell. It only returns if the shell could not be started.
+// The exit code of the installer is never reported then, the shell's is. That loses nothing, as the shell
+// can only be asked for before installing: the install page holds back the shell key.
+func execShell() error {
+	shell := loginShell()
+	return syscall.Exec(shell, []string{shell}, os.Environ())
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// signalMsg is sent when the installer is asked to stop from outside, e.g. by an orchestrator
+type signalMsg struct {
+	sig os.Signal
+}
+
+// forwardSignals hands termination signals to the program as messages, so the model can stop the
+// installer and quit cleanly, restoring the terminal
+func forwardSignals(p *tea.Program) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	go func() {
+		for sig := range sigs {
+			p.Send(signalMsg{sig: sig})
+		}
+	}()
+}
+
+// handleSignal stops a running install before quitting, only quitting once the installer or hook it was
+// running has exited
+func handleSignal(msg signalMsg) tea.Cmd {
+	mainModel.log.Printf("Received %s, quitting", msg.sig)
+	page, ok := currentPage().(*installProcessPage)
+	if !ok || page.progress >= len(page.steps)-1 {
+		mainModel.outcome = outcomeSignaled
+		return tea.Quit
+	}
+	cmd := page.abortAndQuit()
+	mainModel.outcome = outcomeSignaled
+	return cmd
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// signalOn returns an onMsg for runInstall sending SIGTERM to the program once the page gets a message
+// matching the condition
+func signalOn(cond func(tea.Msg) bool) func(tea.Msg) tea.Cmd {
+	sent := false
+	return func(msg tea.Msg) tea.Cmd {
+		if sent || !cond(msg) {
+			return nil
+		}
+		sent = true
+		return handleSignal(signalMsg{sig: syscall.SIGTERM})
+	}
+}
+
+func TestSignalDuringInstaller(t *testing.T) {
+	p := setupInstall(t, &fakeRunner{lines: fakeInstallerOutput, delay: 20 * time.Millisecond})
+	msgs := runInstall(t, p, signalOn(func(msg tea.Msg) bool {
+		_, ok := msg.(RawOutputMsg)
+		return ok
+	}))
+
+	if _, ok := msgs[len(msgs)-1].(tea.QuitMsg); !ok {
+		t.Fatalf("ended with %#v, want to quit", msgs[len(msgs)-1])
+	}
+	select {
+	case <-p.done:
+	default:
+		t.Errorf("quit before the install goroutine was done")
+	}
+	if len(p.logLines) == len(fakeInstallerOutput) {
+		t.Errorf("installer ran to the end")
+	}
+	if !errors.Is(p.err, ErrAborted) || mainModel.outcome != outcomeSignaled {
+		t.Errorf("install ended with %v, outcome %v, want aborted by the signal", p.err, mainModel.outcome)
+	}
+}
+
+func TestSignalDuringPreHook(t *testing.T) {
+	p := setupInstall(t, &fakeRunner{lines: fakeInstallerOutput})
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	// The hook starts a child of its own, which has to go too
+	addHook(t, PreInstallHooks, "sleep 30 & echo $! > "+pidFile+"; echo waiting; wait")
+	begin := time.Now()
+	msgs := runInstall(t, p, signalOn(func(msg tea.Msg) bool {
+		out, ok := msg.(RawOutputMsg)
+		return ok && out.Line == "waiting"
+	}))
+
+	if _, ok := msgs[len(msgs)-1].(tea.QuitMsg); !ok {
+		t.Fatalf("ended with %#v, want to quit", msgs[len(msgs)-1])
+	}
+	if took := time.Since(begin); took > installStopTimeout {
+		t.Errorf("quitting took %s, the hook wasn't killed", took)
+	}
+	if slices.Contains(p.logLines, fakeInstallerOutput[0]) {
+		t.Errorf("installer started after the signal")
+	}
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid := strings.TrimSpace(string(data))
+	if !processGone(pid) {
+		t.Errorf("child %s of the hook still running", pid)
+	}
+}
+
+// processGone reports whether the process exited, leaving a zombie at most as nobody may reap it
+func processGone(pid string) bool {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		stat, err := os.ReadFile(filepath.Join("/proc", pid, "stat"))
+		if err != nil {
+			return true
+		}
+		// The state follows the command name, which is in parentheses
+		if _, rest, _ := strings.Cut(string(stat), ") "); strings.HasPrefix(rest, "Z") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAbortBeforeInstallStarts(t *testing.T) {
+	p := setupInstall(t, &fakeRunner{lines: fakeInstallerOutput})
+	mainModel.disk = filepath.Join(t.TempDir(), "missing")
+	p.Init()
+	if cmd := p.abortAndQuit(); cmd == nil {
+		t.Fatal("no command to quit")
+	} else if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Errorf("didn't quit right away with nothing running")
+	}
+}
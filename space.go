@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/jaypipes/ghw/pkg/block"
+)
+
+// diskSpace is how much space is used on the filesystems of a disk, as far as it can be read
+type diskSpace struct {
+	used        uint64
+	free        uint64
+	mounted     int // Filesystems the used and free figures come from
+	filesystems int // Filesystems found on the disk
+}
+
+// readDiskSpace adds up the used and free space of the mounted filesystems among the partitions.
+// Unmounted filesystems are only counted, reading them would mean mounting them.
+func readDiskSpace(partitions []*block.Partition) diskSpace {
+	var space diskSpace
+	for _, part := range partitions {
+		if part.Type == "" || part.Type == "unknown" {
+			continue
+		}
+		space.filesystems++
+		if part.MountPoint == "" {
+			continue
+		}
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(part.MountPoint, &st); err != nil {
+			continue
+		}
+		space.mounted++
+		space.used += (st.Blocks - st.Bfree) * uint64(st.Bsize)
+		space.free += st.Bavail * uint64(st.Bsize)
+	}
+	return space
+}
+
+func (s diskSpace) String() string {
+	switch {
+	case s.filesystems == 0:
+		return "no filesystem"
+	case s.mounted == 0:
+		return fmt.Sprintf("%d filesystems, not mounted so usage unknown", s.filesystems)
+	case s.mounted < s.filesystems:
+		return fmt.Sprintf("%s used, %s free on %d of %d filesystems", humanSize(s.used), humanSize(s.free), s.mounted, s.filesystems)
+	}
+	return fmt.Sprintf("%s used, %s free", humanSize(s.used), humanSize(s.free))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// hardwareProbedMsg is sent once the initial disk probe is done
+type hardwareProbedMsg struct {
+	err error
+}
+
+// probeHardwareCmd runs the initial disk probe, filling the disk cache so the disk selection is instant
+func probeHardwareCmd() tea.Cmd {
+	return func() tea.Msg {
+		_, err := cachedDisks(false)
+		return hardwareProbedMsg{err: err}
+	}
+}
+
+// newSplashSpinner returns the spinner shown while probing the hardware
+func newSplashSpinner() spinner.Model {
+	s := spinner.New(spinner.WithSpinner(glyphs.Spinner))
+	s.Style = lipgloss.NewStyle().Foreground(kairosAccent)
+	return s
+}
+
+// updateSplash handles the messages while probing. It returns whether the message was fully handled.
+func updateSplash(msg tea.Msg) (tea.Cmd, bool) {
+	switch msg := msg.(type) {
+	case hardwareProbedMsg:
+		if msg.err != nil {
+			mainModel.log.Printf("Error probing hardware: %v", msg.err)
+		}
+		mainModel.probing = false
+		return offerRescue(), true
+	case spinner.TickMsg:
+		// Pages may have their own spinner ticking too, let them see it
+		var cmd tea.Cmd
+		mainModel.splash, cmd = mainModel.splash.Update(msg)
+		return cmd, false
+	case tea.KeyMsg:
+		// Nothing to interact with yet, but still allow leaving
+		if msg.String() == "ctrl+c" {
+			return tea.Quit, true
+		}
+		return nil, true
+	}
+	return nil, false
+}
+
+// splashView is shown until the terminal size is known and the hardware probed
+func splashView() string {
+	s := mainModel.splash.View() + " Detecting hardware..."
+	if mainModel.width == 0 || mainModel.height == 0 {
+		return s
+	}
+	title := lipgloss.NewStyle().Bold(true).Foreground(kairosHighlight).Render(mainModel.title)
+	return lipgloss.Place(mainModel.width, mainModel.height, lipgloss.Center, lipgloss.Center, title+"\n\n"+s)
+}
+
+// offerRescue starts at the welcome page instead of the disk selection when an existing installation is
+// found, so it can be rescued instead of reinstalled
+func offerRescue() tea.Cmd {
+	if mainModel.skipWelcome || mainModel.currentPageID != "disk_selection" || len(mainModel.navigationStack) > 0 || !pageExists("welcome") {
+		return nil
+	}
+	disks, err := cachedDisks(false)
+	if err != nil {
+		return nil
+	}
+	for _, disk := range disks {
+		if len(disk.kairosLabels()) > 0 {
+			mainModel.log.Printf("Existing installation found on %s, offering to rescue it", disk.name)
+			mainModel.currentPageID = "welcome"
+			return currentPage().Init()
+		}
+	}
+	return nil
+}
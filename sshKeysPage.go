@@ -1,43 +1,173 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-// SSH Keys Page
+// SSH Keys Page, manages the keys of one of the users
 type sshKeysPage struct {
 	mode     int // 0 = list view, 1 = add key input
 	cursor   int
-	sshKeys  []string
+	user     int // Index in mainModel.users of the user whose keys are shown
 	keyInput textinput.Model
+
+	// Single level undo buffer for the last deleted key
+	deletedKey string
+	deletedIdx int
+	canUndo    bool
+
+	// Lookup of the keys behind a shorthand as it is typed
+	lookupSeq    int                // Bumped on every change of the input, older lookups are stale
+	lookupCancel context.CancelFunc // Cancels the lookup in flight, if any
+	lookup       string             // Outcome of the lookup of the current input
+}
+
+// sshKeyProviders are the shorthand prefixes kairos resolves to the keys published by the user on that provider
+var sshKeyProviders = []string{"github:", "gitlab:"}
+
+// isKeyShorthand reports whether the key is a provider shorthand like github:USER. Those are only turned
+// into actual keys by the installed system, so they can't be verified here and stay pending.
+func isKeyShorthand(key string) bool {
+	for _, provider := range sshKeyProviders {
+		if strings.HasPrefix(key, provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// pendingKeys counts the shorthand keys of the users
+func pendingKeys(users []userAccount) int {
+	n := 0
+	for _, u := range users {
+		for _, key := range u.SSHKeys {
+			if isKeyShorthand(key) {
+				n++
+			}
+		}
+	}
+	return n
 }
 
 func newSSHKeysPage() *sshKeysPage {
 	keyInput := textinput.New()
 	keyInput.Placeholder = "github:USERNAME or gitlab:USERNAME"
 	keyInput.Width = 60
+	keyInput.SetSuggestions(sshKeyProviders)
+	keyInput.ShowSuggestions = true
 
 	return &sshKeysPage{
 		mode:     0,
 		cursor:   0,
-		sshKeys:  []string{},
 		keyInput: keyInput,
 	}
 }
 
 func (p *sshKeysPage) Init() tea.Cmd {
+	// Undo only applies while staying on the page, and a key left half typed is dropped
+	p.clearUndo()
+	p.DiscardInput()
+	if p.user >= len(mainModel.users) {
+		// Default to the primary user
+		p.user = 0
+		p.cursor = 0
+	}
 	return nil
 }
 
+// forUser shows the keys of the user at the given index of mainModel.users
+func (p *sshKeysPage) forUser(index int) {
+	p.user = index
+	p.cursor = 0
+	p.clearUndo()
+}
+
+// keys returns the keys of the selected user
+func (p *sshKeysPage) keys() []string {
+	if p.user >= len(mainModel.users) {
+		return nil
+	}
+	return mainModel.users[p.user].SSHKeys
+}
+
+// setKeys replaces the keys of the selected user
+func (p *sshKeysPage) setKeys(keys []string) {
+	if p.user < len(mainModel.users) {
+		mainModel.users[p.user].SSHKeys = keys
+	}
+}
+
+func (p *sshKeysPage) clearUndo() {
+	p.deletedKey = ""
+	p.deletedIdx = 0
+	p.canUndo = false
+}
+
+// scheduleLookup restarts the wait before looking up the input, dropping any lookup of an older value
+func (p *sshKeysPage) scheduleLookup() tea.Cmd {
+	p.stopLookup()
+	value := strings.TrimSpace(p.keyInput.Value())
+	if _, user, _ := strings.Cut(value, ":"); !isKeyShorthand(value) || user == "" {
+		return nil
+	}
+	return debounceKeyLookup(p.lookupSeq, value)
+}
+
+// stopLookup cancels the pending and in flight lookups
+func (p *sshKeysPage) stopLookup() {
+	p.lookupSeq++
+	p.lookup = ""
+	if p.lookupCancel != nil {
+		p.lookupCancel()
+		p.lookupCancel = nil
+	}
+}
+
 func (p *sshKeysPage) Update(msg tea.Msg) (Page, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
+	case keyLookupTickMsg:
+		if msg.seq != p.lookupSeq || p.mode != 1 {
+			return p, nil // Typed on since, a newer tick is on its way
+		}
+		var ctx context.Context
+		ctx, p.lookupCancel = context.WithCancel(context.Background())
+		p.lookup = "Looking up " + msg.key + "..."
+		return p, lookupKeysCmd(ctx, msg.key)
+	case keyLookupMsg:
+		if msg.key != strings.TrimSpace(p.keyInput.Value()) || errors.Is(msg.err, context.Canceled) {
+			return p, nil
+		}
+		switch {
+		case msg.err != nil:
+			mainModel.log.Printf("Looking up %s: %v", msg.key, msg.err)
+			p.lookup = fmt.Sprintf("Could not check %s: %v", msg.key, msg.err)
+		case msg.count == 0:
+			p.lookup = fmt.Sprintf("%s publishes no keys, nobody could log in with it", msg.key)
+		default:
+			p.lookup = fmt.Sprintf("%s %s publishes %d keys", glyphs.Check, msg.key, msg.count)
+		}
+		return p, nil
 	case tea.KeyMsg:
+		if len(mainModel.users) == 0 {
+			// Keys belong to a user, so one has to be added first
+			switch msg.String() {
+			case "enter":
+				return p, func() tea.Msg { return GoToPageMsg{PageID: "users"} }
+			case "esc":
+				return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+			}
+			return p, nil
+		}
+		keys := p.keys()
 		if p.mode == 0 { // List view
 			switch msg.String() {
 			case "up", "k":
@@ -45,26 +175,57 @@ func (p *sshKeysPage) Update(msg tea.Msg) (Page, tea.Cmd) {
 					p.cursor--
 				}
 			case "down", "j":
-				if p.cursor < len(p.sshKeys) { // +1 for "Add new key" option
+				if p.cursor < len(keys) { // +1 for "Add new key" option
+					p.cursor++
+				}
+			case "shift+up", "K":
+				// Move the selected key up, the first key is the primary one
+				if p.cursor > 0 && p.cursor < len(keys) {
+					keys[p.cursor-1], keys[p.cursor] = keys[p.cursor], keys[p.cursor-1]
+					p.cursor--
+					p.clearUndo()
+				}
+			case "shift+down", "J":
+				if p.cursor < len(keys)-1 {
+					keys[p.cursor+1], keys[p.cursor] = keys[p.cursor], keys[p.cursor+1]
 					p.cursor++
+					p.clearUndo()
+				}
+			case "left", "h":
+				if p.user > 0 {
+					p.forUser(p.user - 1)
+				}
+			case "right", "l":
+				if p.user < len(mainModel.users)-1 {
+					p.forUser(p.user + 1)
 				}
 			case "d":
 				// Delete selected key
-				if p.cursor < len(p.sshKeys) {
-					p.sshKeys = append(p.sshKeys[:p.cursor], p.sshKeys[p.cursor+1:]...)
-					mainModel.sshKeys = append(mainModel.sshKeys[:p.cursor], mainModel.sshKeys[p.cursor+1:]...)
-					if p.cursor >= len(p.sshKeys) && p.cursor > 0 {
+				if p.cursor < len(keys) {
+					p.deletedKey = keys[p.cursor]
+					p.deletedIdx = p.cursor
+					p.canUndo = true
+					p.setKeys(append(keys[:p.cursor], keys[p.cursor+1:]...))
+					if p.cursor >= len(p.keys()) && p.cursor > 0 {
 						p.cursor--
 					}
 				}
+			case "u":
+				// Restore the last deleted key at its original position
+				if p.canUndo {
+					p.setKeys(append(keys[:p.deletedIdx], append([]string{p.deletedKey}, keys[p.deletedIdx:]...)...))
+					p.cursor = p.deletedIdx
+					p.clearUndo()
+				}
 			case "a", "enter":
-				if p.cursor == len(p.sshKeys) {
+				if p.cursor == len(keys) {
 					// Add new key
 					p.mode = 1
 					p.keyInput.Focus()
 					return p, textinput.Blink
 				}
 			case "esc":
+				p.clearUndo()
 				// Go back to customization page
 				return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
 			}
@@ -72,22 +233,35 @@ func (p *sshKeysPage) Update(msg tea.Msg) (Page, tea.Cmd) {
 			switch msg.String() {
 			case "esc":
 				p.mode = 0
+				p.stopLookup()
 				p.keyInput.Blur()
 				p.keyInput.SetValue("")
 				// Go back to customization page
 				return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+			case "up", "down":
+				// Up/down cycle through the matching providers while there are any
+				if len(p.keyInput.MatchedSuggestions()) == 0 {
+					fieldHistory("ssh_key").recall(&p.keyInput, msg.String())
+					return p, p.scheduleLookup()
+				}
 			case "enter":
+				fieldHistory("ssh_key").add(p.keyInput.Value())
 				if p.keyInput.Value() != "" {
-					p.sshKeys = append(p.sshKeys, p.keyInput.Value())
-					mainModel.sshKeys = append(mainModel.sshKeys, p.keyInput.Value())
+					p.setKeys(append(keys, p.keyInput.Value()))
+					p.clearUndo()
 					p.mode = 0
+					p.stopLookup()
 					p.keyInput.Blur()
 					p.keyInput.SetValue("")
-					p.cursor = len(p.sshKeys) // Point to "Add new key" option
+					p.cursor = len(p.keys()) // Point to "Add new key" option
 					return p, textinput.Blink
 				}
 			}
+			before := p.keyInput.Value()
 			p.keyInput, cmd = p.keyInput.Update(msg)
+			if p.keyInput.Value() != before {
+				cmd = tea.Batch(cmd, p.scheduleLookup())
+			}
 		}
 	}
 
@@ -97,33 +271,44 @@ func (p *sshKeysPage) Update(msg tea.Msg) (Page, tea.Cmd) {
 func (p *sshKeysPage) View() string {
 	s := "SSH Keys Management\n\n"
 
+	if len(mainModel.users) == 0 {
+		return s + "SSH keys are added to a user, but there are no users yet.\n\nPress enter to add one."
+	}
+
+	s += fmt.Sprintf("Keys for user: %s", mainModel.users[p.user].Name)
+	if len(mainModel.users) > 1 {
+		s += fmt.Sprintf(" (%d of %d, ←/→ to switch)", p.user+1, len(mainModel.users))
+	}
+	s += "\n\n"
+
 	if p.mode == 0 {
+		keys := p.keys()
 		s += "Current SSH Keys:\n\n"
 
-		for i, key := range p.sshKeys {
-			cursor := " "
-			if p.cursor == i {
-				cursor = lipgloss.NewStyle().Foreground(kairosAccent).Render(">")
-			}
+		for i, key := range keys {
 			// Truncate long keys for display
 			displayKey := key
 			if len(displayKey) > 50 {
 				displayKey = displayKey[:47] + "..."
 			}
-			s += fmt.Sprintf("%s %s\n", cursor, displayKey)
+			if isKeyShorthand(key) {
+				displayKey += " " + lipgloss.NewStyle().Faint(true).Render(glyphs.Pending+" pending, fetched by the installed system")
+			}
+			s += listItem(i, p.cursor, len(keys)+1, displayKey) + "\n"
 		}
 
 		// Add "Add new key" option
-		cursor := " "
-		if p.cursor == len(p.sshKeys) {
-			cursor = lipgloss.NewStyle().Foreground(kairosAccent).Render(">")
-		}
-		s += fmt.Sprintf("%s + Add new SSH key\n", cursor)
-
+		s += listItem(len(keys), p.cursor, len(keys)+1, "+ Add new SSH key") + "\n"
 		s += "\nPress 'd' to delete selected key"
+		if p.canUndo {
+			s += ", 'u' to undo the last deletion"
+		}
 	} else {
 		s += "Add SSH Public Key:\n\n"
-		s += p.keyInput.View() + "\n\n"
+		s += inputView(p.keyInput) + "\n\n"
+		if p.lookup != "" {
+			s += lipgloss.NewStyle().Faint(true).Render(p.lookup) + "\n\n"
+		}
 		s += "Paste your SSH public key above."
 	}
 
@@ -135,10 +320,31 @@ func (p *sshKeysPage) Title() string {
 }
 
 func (p *sshKeysPage) Help() string {
+	if len(mainModel.users) == 0 {
+		return "enter: add a user • esc: back"
+	}
 	if p.mode == 0 {
-		return "↑/k: up • ↓/j: down • enter/a: add key • d: delete • esc: back"
+		help := "↑/k: up • ↓/j: down • shift+↑/↓: move key • enter/a: add key • d: delete • u: undo delete • esc: back"
+		if len(mainModel.users) > 1 {
+			help += " • ←/→: switch user"
+		}
+		return help
 	}
-	return "Type SSH key • enter: add • esc: cancel"
+	return "Type SSH key • ↑/↓: previous keys • tab: complete provider • ctrl+n/ctrl+p: next/previous suggestion • enter: add • esc: cancel"
 }
 
 func (p *sshKeysPage) ID() string { return "ssh_keys" }
+
+func (p *sshKeysPage) BackTarget() string { return "customization" }
+
+func (p *sshKeysPage) TakingTextInput() bool { return p.mode == 1 }
+
+// HasUnsavedInput reports whether a key is being typed in, a pasted key is long to get back
+func (p *sshKeysPage) HasUnsavedInput() bool { return p.mode == 1 && p.keyInput.Value() != "" }
+
+func (p *sshKeysPage) DiscardInput() {
+	p.mode = 0
+	p.stopLookup()
+	p.keyInput.Blur()
+	p.keyInput.SetValue("")
+}
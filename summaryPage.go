@@ -2,57 +2,190 @@ package main
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 // Summary Page
 type summaryPage struct {
+	noBackTarget
+
 	cursor  int
 	options []string
+
+	saving      bool              // Asking for the name of the profile to save
+	nameInput   textinput.Model   // Name of the profile to save
+	withSecrets bool              // Include the password and secret fields in the saved profile
+	status      string            // Outcome of the last profile save or clipboard copy
+	violations  []schemaViolation // Keys of the config the schema check flagged
 }
 
 func newSummaryPage() *summaryPage {
-	return &summaryPage{}
+	nameInput := textinput.New()
+	nameInput.Placeholder = "my-profile"
+	nameInput.Width = 30
+	return &summaryPage{nameInput: nameInput}
 }
 
 func (p *summaryPage) Init() tea.Cmd {
+	p.saving = false
+	p.status = ""
+	p.violations = checkConfig(NewInstallConfig(mainModel))
+	for _, v := range p.violations {
+		mainModel.log.Printf("Config check: %s", v)
+	}
 	return nil
 }
 
 func (p *summaryPage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	if p.saving {
+		return p.updateSaving(msg)
+	}
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
+			if mainModel.rescue {
+				return p, p.applyToRescue()
+			}
 			return p, func() tea.Msg { return GoToPageMsg{PageID: "install_process"} }
+		case "c":
+			p.status = copyConfigToClipboard()
+		case "s":
+			p.saving = true
+			p.status = ""
+			p.nameInput.SetValue("")
+			return p, p.nameInput.Focus()
 		}
 	}
 	return p, nil
 }
 
+// applyToRescue writes the answers to the installation being rescued and goes back to the rescue page
+func (p *summaryPage) applyToRescue() tea.Cmd {
+	var cmd tea.Cmd
+	cfg := rescueConfig()
+	for _, page := range mainModel.pages {
+		if rp, ok := page.(*rescuePage); ok {
+			cmd = rp.start("Applying configuration", func(disk diskStruct) (string, error) {
+				return applyRescueConfig(disk, cfg)
+			})
+		}
+	}
+	return tea.Batch(cmd, func() tea.Msg { return GoToPageMsg{PageID: "rescue"} })
+}
+
+// updateSaving handles the keys while asking for the profile name
+func (p *summaryPage) updateSaving(msg tea.Msg) (Page, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "tab":
+			p.withSecrets = !p.withSecrets
+			return p, nil
+		case "esc":
+			p.saving = false
+			p.nameInput.Blur()
+			return p, nil
+		case "enter":
+			path, err := saveProfile(p.nameInput.Value(), p.withSecrets)
+			if err != nil {
+				mainModel.log.Printf("Error saving profile: %v", err)
+				p.status = fmt.Sprintf("Error saving profile: %v", err)
+				return p, nil
+			}
+			mainModel.log.Printf("Saved profile to %s", path)
+			p.status = fmt.Sprintf("Profile saved to %s", path)
+			p.saving = false
+			p.nameInput.Blur()
+			return p, nil
+		}
+	}
+	var cmd tea.Cmd
+	p.nameInput, cmd = p.nameInput.Update(msg)
+	return p, cmd
+}
+
 func (p *summaryPage) View() string {
 	s := "Installation Summary\n\n"
 	s += "Selected Disk: " + mainModel.disk + "\n\n"
 	s += "Configuration Summary:\n"
-	if mainModel.username != "" {
-		s += fmt.Sprintf("  - Username: %s\n", mainModel.username)
+	if len(mainModel.users) > 0 {
+		s += fmt.Sprintf("  - Users: %s\n", strings.Join(userNames(mainModel.users), ", "))
 	} else {
-		s += "  - Username: Not set\n"
+		s += "  - Users: Not set\n"
+	}
+	for _, u := range mainModel.users {
+		if len(u.SSHKeys) > 0 {
+			s += fmt.Sprintf("  - SSH Keys for %s: %s\n", u.Name, u.SSHKeys)
+		}
 	}
-	if len(mainModel.sshKeys) > 0 {
-		s += fmt.Sprintf("  - SSH Keys: %s\n", mainModel.sshKeys)
+
+	if hostname, ok := valueForSectionInMainModel(hostnameSection); ok {
+		s += fmt.Sprintf("  - Hostname: %v\n", hostname)
+	} else if mainModel.defaultHostname != "" && !mainModel.rescue {
+		s += fmt.Sprintf("  - Hostname: %s (generated)\n", mainModel.defaultHostname)
 	} else {
-		s += "  - SSH Keys: Not set\n"
+		s += "  - Hostname: Not set\n"
 	}
 
-	if len(mainModel.extraFields) > 0 {
-		s += "  - Extra Options:\n"
-		for key, value := range mainModel.extraFields {
-			s += fmt.Sprintf("    - %s: %v\n", key, value)
+	if mainModel.baseConfigURL != "" {
+		s += fmt.Sprintf("  - Base Config: %s\n", mainModel.baseConfigURL)
+	}
+	if mainModel.kernelArgs != "" {
+		s += fmt.Sprintf("  - Kernel Arguments: %s\n", mainModel.kernelArgs)
+	}
+	if mainModel.filesystem != "" {
+		s += fmt.Sprintf("  - Filesystem: %s\n", mainModel.filesystem)
+	} else {
+		s += "  - Filesystem: Agent default\n"
+	}
+	if mainModel.persistentSize > 0 {
+		s += fmt.Sprintf("  - Persistent Size: %s\n", humanSize(mainModel.persistentSize*1024*1024))
+	} else {
+		s += "  - Persistent Size: Remaining space\n"
+	}
+	if mainModel.imageMirror != "" {
+		s += fmt.Sprintf("  - Image Mirror: %s\n", mainModel.imageMirror)
+	}
+	for _, step := range mainModel.customSteps {
+		s += fmt.Sprintf("  - Custom Step: %s at %s\n", step.Name, step.Stage)
+	}
+
+	extra := ""
+	for key, value := range mainModel.extraFields {
+		// Shown on its own above
+		if key == hostnameSection {
+			continue
 		}
+		extra += fmt.Sprintf("    - %s: %v\n", key, value)
+	}
+	if extra != "" {
+		s += "  - Extra Options:\n" + extra
 	} else {
 		s += "  - Extra Options: Not set\n"
 	}
+	if len(p.violations) > 0 {
+		warn := lipgloss.NewStyle().Foreground(kairosHighlight2)
+		s += "\n" + warn.Render(fmt.Sprintf("%s Config check found %d problems, the agent may reject or ignore these keys:", glyphs.Warning, len(p.violations))) + "\n"
+		for _, v := range p.violations {
+			s += warn.Render("  - "+v.String()) + "\n"
+		}
+	}
+
+	if p.saving {
+		check := glyphs.Unchecked
+		if p.withSecrets {
+			check = glyphs.Checked
+		}
+		s += "\nProfile name: " + p.nameInput.View() + "\n"
+		s += fmt.Sprintf("%s Include password and secrets\n", check)
+	}
+	if p.status != "" {
+		s += "\n" + lipgloss.NewStyle().Foreground(kairosAccent).Render(p.status) + "\n"
+	}
 
 	return s
 }
@@ -62,7 +195,31 @@ func (p *summaryPage) Title() string {
 }
 
 func (p *summaryPage) Help() string {
-	return "Press enter to start the installation process.\n"
+	if p.saving {
+		return "Type profile name • tab: toggle secrets • enter: save • esc: cancel"
+	}
+	if mainModel.rescue {
+		return "Press enter to apply the configuration to the existing installation • s: save profile • c: copy config"
+	}
+	return "Press enter to start the installation process • s: save profile • c: copy config"
 }
 
 func (p *summaryPage) ID() string { return "summary" }
+
+// TakingTextInput reports whether the profile name is being typed
+func (p *summaryPage) TakingTextInput() bool {
+	return p.saving
+}
+
+// Prompting reports whether the profile name is being asked for, ESC cancels saving
+func (p *summaryPage) Prompting() bool {
+	return p.saving
+}
+
+// AutoAdvance starts the installation. A rescue always waits for the user.
+func (p *summaryPage) AutoAdvance() tea.Cmd {
+	if mainModel.rescue {
+		return nil
+	}
+	return func() tea.Msg { return GoToPageMsg{PageID: "install_process"} }
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"io"
+	"log"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSummaryEscCancelsSavingProfile(t *testing.T) {
+	oldModel := mainModel
+	t.Cleanup(func() { mainModel = oldModel })
+	options, summary := newInstallOptionsPage(), newSummaryPage()
+	mainModel = model{
+		log:             log.New(io.Discard, "", 0),
+		pages:           []Page{options, summary},
+		currentPageID:   summary.ID(),
+		navigationStack: []string{options.ID()},
+	}
+	summary.Init()
+
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("s")}, {Type: tea.KeyRunes, Runes: []rune("x")}, {Type: tea.KeyEsc}} {
+		mainModel.Update(key)
+	}
+	if mainModel.currentPageID != summary.ID() || mainModel.showDiscardConfirm {
+		t.Errorf("cancelling the profile name left to %s, asking to discard: %v", mainModel.currentPageID, mainModel.showDiscardConfirm)
+	}
+	if summary.saving {
+		t.Errorf("still asking for the profile name")
+	}
+
+	// Without the prompt ESC leaves the summary as usual
+	mainModel.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if mainModel.currentPageID != options.ID() {
+		t.Errorf("ESC on the summary went to %s, want %s", mainModel.currentPageID, options.ID())
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import "github.com/charmbracelet/lipgloss"
+
+// theme is a color palette for the UI
+type theme struct {
+	Name       string
+	Bg         lipgloss.Color // Background
+	Highlight  lipgloss.Color // Title
+	Highlight2 lipgloss.Color // Minor alerts or secondary info
+	Accent     lipgloss.Color // Cursor, ticks and popups
+	Border     lipgloss.Color // Frame around the UI
+	Text       lipgloss.Color // Regular text
+	Success    lipgloss.Color // Progress bar of a finished install
+	Failure    lipgloss.Color // Progress bar of a failed install
+	Bold       bool           // Make highlighted elements bold, for themes that can't rely on color
+}
+
+var (
+	// defaultTheme is the true color palette
+	defaultTheme = theme{
+		Name:       "default",
+		Bg:         lipgloss.Color("#03153a"), // Deep blue background
+		Highlight:  lipgloss.Color("#e56a44"), // Orange highlight
+		Highlight2: lipgloss.Color("#d54b11"), // Red-orange highlight
+		Accent:     lipgloss.Color("#ee5007"), // Accent orange
+		Border:     lipgloss.Color("#e56a44"), // Use highlight for border
+		Text:       lipgloss.Color("#ffffff"), // White text for contrast
+		Success:    lipgloss.Color("#3fb950"), // Green
+		Failure:    lipgloss.Color("#f85149"), // Red
+	}
+	// consoleTheme is the fallback for terminal environments that do not support true color
+	consoleTheme = theme{
+		Name:       "console",
+		Bg:         lipgloss.Color("0"), // Black
+		Text:       lipgloss.Color("7"), // White
+		Highlight:  lipgloss.Color("9"), // Bright Red (for title)
+		Highlight2: lipgloss.Color("1"), // Red (for minor alerts or secondary info)
+		Accent:     lipgloss.Color("5"), // Magenta (or "13" if brighter is OK)
+		Border:     lipgloss.Color("9"), // Bright Red (matches highlight)
+		Success:    lipgloss.Color("2"), // Green
+		Failure:    lipgloss.Color("1"), // Red
+	}
+	// highContrastTheme is pure white on black, for low vision users or poor monitors
+	highContrastTheme = theme{
+		Name:       "high-contrast",
+		Bg:         lipgloss.Color("0"),
+		Text:       lipgloss.Color("15"),
+		Highlight:  lipgloss.Color("15"),
+		Highlight2: lipgloss.Color("15"),
+		Accent:     lipgloss.Color("15"),
+		Border:     lipgloss.Color("15"),
+		Success:    lipgloss.Color("15"),
+		Failure:    lipgloss.Color("15"),
+		Bold:       true,
+	}
+)
+
+var (
+	// baseTheme is the theme picked for the terminal, restored when toggling high contrast off
+	baseTheme = defaultTheme
+	// currentTheme is the theme in use
+	currentTheme = defaultTheme
+)
+
+// applyTheme switches the UI colors to the given theme. Styles are built on every render, so this takes
+// effect on the next frame.
+func applyTheme(t theme) {
+	currentTheme = t
+	kairosBg = t.Bg
+	kairosHighlight = t.Highlight
+	kairosHighlight2 = t.Highlight2
+	kairosAccent = t.Accent
+	kairosBorder = t.Border
+	kairosText = t.Text
+	kairosSuccess = t.Success
+	kairosFailure = t.Failure
+}
+
+// toggleHighContrast switches between the high contrast theme and the one picked for the terminal
+func toggleHighContrast() {
+	if currentTheme.Name == highContrastTheme.Name {
+		applyTheme(baseTheme)
+	} else {
+		applyTheme(highContrastTheme)
+	}
+}
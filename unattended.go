@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultUnattendedPages are the pages that continue on their own after the unattended timeout, unless
+// configured otherwise. Disk selection and its confirmation are left out so nothing is wiped by default.
+const defaultUnattendedPages = "welcome,install_options,customization,summary"
+
+// autoAdvanceDelay is the unattended timeout used by --auto, leaving a moment to interrupt it
+const autoAdvanceDelay = 3 * time.Second
+
+// unattendedPage is implemented by pages able to continue on their own with their defaults
+type unattendedPage interface {
+	// AutoAdvance returns the command continuing with the defaults, or nil if the page can't do it yet
+	AutoAdvance() tea.Cmd
+}
+
+// idleTimeoutMsg is sent when the user didn't press anything for the unattended timeout. Only the one
+// matching the current idle generation counts, older timers were reset by a key press.
+type idleTimeoutMsg struct {
+	gen int
+}
+
+// parseUnattendedPages turns a comma separated list of page IDs into a set
+func parseUnattendedPages(list string) map[string]bool {
+	pages := map[string]bool{}
+	for _, id := range strings.Split(list, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			pages[id] = true
+		}
+	}
+	return pages
+}
+
+// autoAdvances reports whether the current page continues on its own after the unattended timeout
+func autoAdvances(p Page) bool {
+	if mainModel.unattendedTimeout <= 0 || !mainModel.unattendedPages[p.ID()] {
+		return false
+	}
+	_, ok := p.(unattendedPage)
+	return ok
+}
+
+// restartIdleTimer starts the unattended timeout again, invalidating any timer already running
+func restartIdleTimer() tea.Cmd {
+	mainModel.idleGen++
+	p := currentPage()
+	if p == nil || !autoAdvances(p) {
+		return nil
+	}
+	gen := mainModel.idleGen
+	return tea.Tick(mainModel.unattendedTimeout, func(time.Time) tea.Msg { return idleTimeoutMsg{gen: gen} })
+}
+
+// handleIdleTimeout continues with the defaults of the current page if nothing happened since the timer started
+func handleIdleTimeout(msg idleTimeoutMsg) tea.Cmd {
+	if msg.gen != mainModel.idleGen {
+		return nil
+	}
+	if mainModel.probing {
+		// Nothing is shown yet, start counting once the page is
+		return restartIdleTimer()
+	}
+	if mainModel.showQuitConfirm || mainModel.showDiscardConfirm || mainModel.showConfigPreview || mainModel.palette != nil {
+		return nil
+	}
+	p := currentPage()
+	if p == nil || !autoAdvances(p) {
+		return nil
+	}
+	cmd := p.(unattendedPage).AutoAdvance()
+	if cmd == nil {
+		// Not ready yet, try again later
+		return restartIdleTimer()
+	}
+	mainModel.log.Printf("No input for %s on %s, continuing with the defaults", mainModel.unattendedTimeout, p.ID())
+	return cmd
+}
+
+// unattendedHelp tells the user that the current page continues on its own, if it does
+func unattendedHelp(p Page) string {
+	if !autoAdvances(p) {
+		return ""
+	}
+	return fmt.Sprintf(" • continues with defaults after %s without input", mainModel.unattendedTimeout)
+}
@@ -2,18 +2,23 @@ package main
 
 import (
 	"fmt"
-	
+	"strings"
+
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
-// User Password Page
+// User Password Page, edits one of the users from the users page
 type userPasswordPage struct {
-	focusedField  int // 0 = username, 1 = password
+	focusedField  int // 0 = username, 1 = password, 2 = groups, 3 = shell
 	usernameInput textinput.Model
 	passwordInput textinput.Model
-	username      string
-	password      string
+	groupsInput   textinput.Model
+	shellInput    textinput.Model
+	sudoNoPasswd  bool
+	index         int // Index of the user being edited in mainModel.users, len(mainModel.users) for a new one
+	err           string
 }
 
 func newUserPasswordPage() *userPasswordPage {
@@ -27,10 +32,22 @@ func newUserPasswordPage() *userPasswordPage {
 	passwordInput.Placeholder = "Kairos"
 	passwordInput.EchoMode = textinput.EchoPassword
 
+	groupsInput := textinput.New()
+	groupsInput.Width = 40
+	groupsInput.Placeholder = "admin, wheel"
+
+	shellInput := textinput.New()
+	shellInput.Width = 40
+	shellInput.Placeholder = "distro default"
+	shellInput.SetSuggestions(commonShells)
+	shellInput.ShowSuggestions = true
+
 	return &userPasswordPage{
 		focusedField:  0,
 		usernameInput: usernameInput,
 		passwordInput: passwordInput,
+		groupsInput:   groupsInput,
+		shellInput:    shellInput,
 	}
 }
 
@@ -38,6 +55,11 @@ func (p *userPasswordPage) Init() tea.Cmd {
 	return textinput.Blink
 }
 
+// inputs returns the text inputs in focus order
+func (p *userPasswordPage) inputs() []*textinput.Model {
+	return []*textinput.Model{&p.usernameInput, &p.passwordInput, &p.groupsInput, &p.shellInput}
+}
+
 func (p *userPasswordPage) Update(msg tea.Msg) (Page, tea.Cmd) {
 	var cmd tea.Cmd
 
@@ -45,54 +67,172 @@ func (p *userPasswordPage) Update(msg tea.Msg) (Page, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "tab":
-			if p.focusedField == 0 {
-				p.focusedField = 1
-				p.usernameInput.Blur()
-				p.passwordInput.Focus()
-				return p, p.passwordInput.Focus()
-			} else {
-				p.focusedField = 0
-				p.passwordInput.Blur()
-				p.usernameInput.Focus()
-				return p, p.usernameInput.Focus()
+			if s := p.shellInput.CurrentSuggestion(); p.shellInput.Focused() && s != "" && s != p.shellInput.Value() {
+				// Complete the shell first
+				break
+			}
+			p.focusedField, cmd = cycleFocus(p.inputs(), p.focusedField, 1)
+			return p, cmd
+		case "shift+tab":
+			p.focusedField, cmd = cycleFocus(p.inputs(), p.focusedField, -1)
+			return p, cmd
+		case "ctrl+s":
+			p.sudoNoPasswd = !p.sudoNoPasswd
+			return p, nil
+		case "up", "down":
+			// Passwords are not kept around in the history
+			if field, ok := p.historyField(); ok {
+				fieldHistory(field).recall(p.inputs()[p.focusedField], msg.String())
+				return p, nil
 			}
 		case "enter":
+			fieldHistory("username").add(p.usernameInput.Value())
+			fieldHistory("groups").add(p.groupsInput.Value())
 			if p.usernameInput.Value() != "" && p.passwordInput.Value() != "" {
-				p.username = p.usernameInput.Value()
-				mainModel.username = p.username
-				p.password = p.passwordInput.Value()
-				mainModel.password = p.password
-				// Save and go back to customization
-				return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+				if err := p.save(); err != nil {
+					p.err = err.Error()
+					return p, nil
+				}
+				// Save and go back to the users list
+				return p, func() tea.Msg { return GoToPageMsg{PageID: "users"} }
 			}
 		case "esc":
-			// Go back to customization page
-			return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+			// Go back to the users list
+			return p, func() tea.Msg { return GoToPageMsg{PageID: "users"} }
+		}
+	}
+
+	input := p.inputs()[p.focusedField]
+	*input, cmd = input.Update(msg)
+
+	return p, cmd
+}
+
+// historyField returns the name of the history of the focused input, if it keeps one
+func (p *userPasswordPage) historyField() (string, bool) {
+	switch p.inputs()[p.focusedField] {
+	case &p.usernameInput:
+		return "username", true
+	case &p.groupsInput:
+		return "groups", true
+	}
+	return "", false
+}
+
+// HasUnsavedInput reports whether the inputs differ from the user as last saved
+func (p *userPasswordPage) HasUnsavedInput() bool {
+	var user userAccount
+	if p.index < len(mainModel.users) {
+		user = mainModel.users[p.index]
+	}
+	return p.usernameInput.Value() != user.Name ||
+		p.passwordInput.Value() != user.Password ||
+		strings.Join(splitList(p.groupsInput.Value()), ",") != strings.Join(user.Groups, ",") ||
+		strings.TrimSpace(p.shellInput.Value()) != user.Shell ||
+		p.sudoNoPasswd != user.SudoNoPasswd
+}
+
+// DiscardInput refills the inputs with the user as last saved
+func (p *userPasswordPage) DiscardInput() {
+	p.edit(p.index)
+}
+
+// save stores the edited user in mainModel.users
+func (p *userPasswordPage) save() error {
+	name := strings.TrimSpace(p.usernameInput.Value())
+	for i, u := range mainModel.users {
+		if i != p.index && u.Name == name {
+			return fmt.Errorf("user %s already exists", name)
 		}
 	}
+	if err := currentBranding().PasswordPolicy.Check(p.passwordInput.Value()); err != nil {
+		return err
+	}
+	shell := strings.TrimSpace(p.shellInput.Value())
+	if err := validateShell(shell); err != nil {
+		return err
+	}
+	user := userAccount{
+		Name:     name,
+		Password: p.passwordInput.Value(),
+		Groups:   splitList(p.groupsInput.Value()),
+		Shell:    shell,
 
-	if p.focusedField == 0 {
-		p.usernameInput, cmd = p.usernameInput.Update(msg)
+		SudoNoPasswd: p.sudoNoPasswd,
+	}
+	if p.index < len(mainModel.users) {
+		user.SSHKeys = mainModel.users[p.index].SSHKeys
+		mainModel.users[p.index] = user
 	} else {
-		p.passwordInput, cmd = p.passwordInput.Update(msg)
+		mainModel.users = append(mainModel.users, user)
 	}
+	mainModel.log.Printf("Saved user %s", name)
+	return nil
+}
 
-	return p, cmd
+// edit fills the page with the user at the given index of mainModel.users, or empties it to add a new one
+func (p *userPasswordPage) edit(index int) {
+	p.index = index
+	p.err = ""
+	var user userAccount
+	if index < len(mainModel.users) {
+		user = mainModel.users[index]
+	}
+	p.usernameInput.SetValue(user.Name)
+	p.passwordInput.SetValue(user.Password)
+	p.groupsInput.SetValue(strings.Join(user.Groups, ", "))
+	p.shellInput.SetValue(user.Shell)
+	p.sudoNoPasswd = user.SudoNoPasswd
+	for _, input := range p.inputs() {
+		input.Blur()
+	}
+	p.focusedField = 0
+	p.usernameInput.Focus()
+}
+
+// splitList splits a comma separated list, dropping empty entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 func (p *userPasswordPage) View() string {
 	s := "User Account Setup\n\n"
-	s += "Username:\n"
+	s += fieldLabel("Username:", p.focusedField == 0) + "\n"
 	s += p.usernameInput.View() + "\n\n"
-	s += "Password:\n"
-	s += p.passwordInput.View() + "\n\n"
+	s += fieldLabel("Password:", p.focusedField == 1) + "\n"
+	s += p.passwordInput.View() + "\n"
+	if policy := currentBranding().PasswordPolicy.Describe(); policy != "" {
+		s += lipgloss.NewStyle().Faint(true).Render(policy) + "\n"
+	}
+	s += "\n"
+	s += fieldLabel("Groups (comma separated):", p.focusedField == 2) + "\n"
+	s += p.groupsInput.View() + "\n\n"
+	s += fieldLabel("Login shell:", p.focusedField == 3) + "\n"
+	s += p.shellInput.View() + "\n\n"
+	sudo := "[ ]"
+	if p.sudoNoPasswd {
+		sudo = "[x]"
+	}
+	s += fmt.Sprintf("%s Passwordless sudo (ctrl+s)\n", sudo)
+	s += lipgloss.NewStyle().Faint(true).Render("    Convenient on lab machines, but anything running as this user can become root without the password.") + "\n\n"
 
-	if p.username != "" {
-		s += fmt.Sprintf("✓ User configured: %s\n", p.username)
+	if p.index < len(mainModel.users) {
+		s += fmt.Sprintf("%s Editing user: %s\n", glyphs.Check, mainModel.users[p.index].Name)
+	}
+	if p.index == 0 && p.groupsInput.Value() == "" {
+		s += fmt.Sprintf("The primary user is added to %s when no groups are given.\n", strings.Join(defaultPrimaryGroups, ", "))
 	}
 
-	if p.usernameInput.Value() == "" || p.passwordInput.Value() == "" {
-		s += "\nBoth fields are required to continue."
+	if p.err != "" {
+		s += "\n" + lipgloss.NewStyle().Foreground(kairosHighlight2).Render(p.err)
+	} else if p.usernameInput.Value() == "" || p.passwordInput.Value() == "" {
+		s += "\nUsername and password are required to continue."
 	}
 
 	return s
@@ -103,7 +243,14 @@ func (p *userPasswordPage) Title() string {
 }
 
 func (p *userPasswordPage) Help() string {
-	return "tab: switch fields • enter: save and continue"
+	if p.shellInput.Focused() {
+		return "tab: complete shell/next field • ↑/↓: other shells • enter: save and continue"
+	}
+	return "tab/shift+tab: switch fields • ↑/↓: previous values • ctrl+s: toggle passwordless sudo • enter: save and continue"
 }
 
 func (p *userPasswordPage) ID() string { return "user_password" }
+
+func (p *userPasswordPage) BackTarget() string { return "users" }
+
+func (p *userPasswordPage) TakingTextInput() bool { return true }
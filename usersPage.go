@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// userAccount is a user to create on the installed system
+type userAccount struct {
+	Name     string   `json:"name" yaml:"name"`
+	Password string   `json:"password,omitempty" yaml:"password,omitempty"`
+	Groups   []string `json:"groups,omitempty" yaml:"groups,omitempty"`
+	SSHKeys  []string `json:"ssh_keys,omitempty" yaml:"ssh_keys,omitempty"`
+	Shell    string   `json:"shell,omitempty" yaml:"shell,omitempty"` // Login shell, the distro default if empty
+	// SudoNoPasswd lets the user run anything with sudo without typing the password. Handy on lab machines,
+	// but anything running as the user gets root.
+	SudoNoPasswd bool `json:"sudo_nopasswd,omitempty" yaml:"sudo_nopasswd,omitempty"`
+}
+
+// commonShells are the login shells that can be picked for a user
+var commonShells = []string{"/bin/bash", "/bin/sh", "/bin/zsh", "/bin/ash", "/bin/dash", "/usr/bin/bash", "/usr/bin/zsh", "/usr/bin/fish"}
+
+// validateShell checks the shell is one of the common shells, empty leaves it to the distro default
+func validateShell(shell string) error {
+	if shell == "" {
+		return nil
+	}
+	for _, s := range commonShells {
+		if s == shell {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown shell %s, use one of %s", shell, strings.Join(commonShells, ", "))
+}
+
+// defaultPrimaryGroups are the groups of the primary user when none are given
+var defaultPrimaryGroups = []string{"admin"}
+
+// userNames returns the names of the given users, in order
+func userNames(users []userAccount) []string {
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, u.Name)
+	}
+	return names
+}
+
+// Users Page, manages the list of users to create. The first one is the primary user.
+type usersPage struct {
+	cursor  int
+	warning string // Why the last action couldn't be done
+}
+
+func newUsersPage() *usersPage {
+	return &usersPage{}
+}
+
+func (p *usersPage) Init() tea.Cmd {
+	if p.cursor > len(mainModel.users) {
+		p.cursor = len(mainModel.users)
+	}
+	return nil
+}
+
+func (p *usersPage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		p.warning = ""
+		switch msg.String() {
+		case "up", "k":
+			if p.cursor > 0 {
+				p.cursor--
+			}
+		case "down", "j":
+			if p.cursor < len(mainModel.users) { // +1 for "Add new user" option
+				p.cursor++
+			}
+		case "enter", "a":
+			if msg.String() == "a" {
+				p.cursor = len(mainModel.users)
+			}
+			if mainModel.disabledPages["user_password"] {
+				p.warning = "Adding and editing users is disabled by the branding of this installer."
+				return p, nil
+			}
+			// Edit the user under the cursor, or add a new one
+			for _, page := range mainModel.pages {
+				if up, ok := page.(*userPasswordPage); ok {
+					up.edit(p.cursor)
+				}
+			}
+			return p, func() tea.Msg { return GoToPageMsg{PageID: "user_password"} }
+		case "s":
+			// Manage the SSH keys of the user under the cursor
+			if p.cursor < len(mainModel.users) {
+				if mainModel.disabledPages["ssh_keys"] {
+					p.warning = "SSH keys are disabled by the branding of this installer."
+					return p, nil
+				}
+				for _, page := range mainModel.pages {
+					if sp, ok := page.(*sshKeysPage); ok {
+						sp.forUser(p.cursor)
+					}
+				}
+				return p, func() tea.Msg { return GoToPageMsg{PageID: "ssh_keys"} }
+			}
+		case "d":
+			if p.cursor < len(mainModel.users) {
+				mainModel.log.Printf("Removing user %s", mainModel.users[p.cursor].Name)
+				mainModel.users = append(mainModel.users[:p.cursor], mainModel.users[p.cursor+1:]...)
+				if p.cursor >= len(mainModel.users) && p.cursor > 0 {
+					p.cursor--
+				}
+			}
+		case "esc":
+			return p, func() tea.Msg { return GoToPageMsg{PageID: "customization"} }
+		}
+	}
+	return p, nil
+}
+
+func (p *usersPage) View() string {
+	s := "Users\n\n"
+	total := len(mainModel.users) + 1
+	for i, u := range mainModel.users {
+		label := u.Name
+		if i == 0 {
+			label += " (primary)"
+		}
+		if len(u.SSHKeys) > 0 {
+			label += fmt.Sprintf(" - %d SSH keys", len(u.SSHKeys))
+		}
+		if len(u.Groups) > 0 {
+			label += fmt.Sprintf(" - groups: %s", strings.Join(u.Groups, ", "))
+		}
+		if u.Shell != "" {
+			label += fmt.Sprintf(" - shell: %s", u.Shell)
+		}
+		if u.SudoNoPasswd {
+			label += " - passwordless sudo"
+		}
+		s += listItem(i, p.cursor, total, label) + "\n"
+	}
+	s += listItem(len(mainModel.users), p.cursor, total, "+ Add new user") + "\n"
+	if len(mainModel.users) == 0 {
+		s += "\nNo users configured, the installed system won't have any login user."
+	}
+	if p.warning != "" {
+		s += "\n" + lipgloss.NewStyle().Foreground(kairosHighlight2).Render(fmt.Sprintf("%s %s", glyphs.Warning, p.warning))
+	}
+	return s
+}
+
+func (p *usersPage) Title() string {
+	return "Users"
+}
+
+func (p *usersPage) Help() string {
+	return "↑/k: up • ↓/j: down • enter: edit or add • a: add user • s: SSH keys • d: delete • esc: back"
+}
+
+func (p *usersPage) ID() string { return "users" }
+
+func (p *usersPage) BackTarget() string { return "customization" }
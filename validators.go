@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Validator checks a value entered by the user, returning an error explaining why it is not valid
+type Validator func(value string) error
+
+// validators holds the built-in validators that prompts can reference by name in their Validation field
+var validators = map[string]Validator{
+	"ipv4":  validateIPv4,
+	"email": validateEmail,
+	"url":   validateURL,
+	"port":  validatePort,
+
+	"hostname": validateHostname,
+}
+
+// RegisterValidator adds or replaces a named validator
+func RegisterValidator(name string, v Validator) {
+	validators[name] = v
+}
+
+// validate checks the value against the given validation, which is either the name of a registered
+// validator or a regular expression the whole value has to match
+func validate(validation, value string) error {
+	if validation == "" {
+		return nil
+	}
+	if v, ok := validators[validation]; ok {
+		return v(value)
+	}
+	re, err := regexp.Compile("^(?:" + validation + ")$")
+	if err != nil {
+		mainModel.log.Printf("Invalid validation pattern %q: %v", validation, err)
+		return fmt.Errorf("invalid validation pattern %q", validation)
+	}
+	if !re.MatchString(value) {
+		return fmt.Errorf("value must match %s", validation)
+	}
+	return nil
+}
+
+func validateIPv4(value string) error {
+	ip := net.ParseIP(value)
+	if ip == nil || ip.To4() == nil {
+		return errors.New("not a valid IPv4 address")
+	}
+	return nil
+}
+
+func validateEmail(value string) error {
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value {
+		return errors.New("not a valid email address")
+	}
+	return nil
+}
+
+func validateURL(value string) error {
+	u, err := url.ParseRequestURI(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.New("not a valid URL, expected something like https://example.com")
+	}
+	return nil
+}
+
+func validatePort(value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return errors.New("not a valid port, expected a number between 1 and 65535")
+	}
+	return nil
+}
+
+// hostnameLabel is a single label of an RFC 1123 hostname
+var hostnameLabel = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+func validateHostname(value string) error {
+	if len(value) > 253 {
+		return errors.New("hostname is longer than 253 characters")
+	}
+	for _, label := range strings.Split(value, ".") {
+		if !hostnameLabel.MatchString(label) {
+			return fmt.Errorf("%q is not a valid hostname label, use up to 63 letters, digits or hyphens, not starting or ending with a hyphen", label)
+		}
+	}
+	return nil
+}
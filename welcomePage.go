@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Welcome Page, shown first when the branding provides a welcome text or a license, or an existing installation that
+// could be rescued is found
+type welcomePage struct {
+	noBackTarget
+
+	text    string
+	eula    string // License accepted by beginning the installation
+	cursor  int
+	options []string
+}
+
+func newWelcomePage(text string) *welcomePage {
+	return &welcomePage{
+		text: text,
+		eula: EULAText(),
+		options: []string{
+			"Begin Installation",
+			"Rescue an existing installation",
+			"Drop to a shell",
+		},
+	}
+}
+
+func (p *welcomePage) Init() tea.Cmd {
+	return nil
+}
+
+func (p *welcomePage) Update(msg tea.Msg) (Page, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if p.cursor > 0 {
+				p.cursor--
+			}
+		case "down", "j":
+			if p.cursor < len(p.options)-1 {
+				p.cursor++
+			}
+		case "enter":
+			if p.cursor == 2 {
+				return p, quitToShell()
+			}
+			mainModel.rescue = p.cursor == 1
+			if mainModel.rescue {
+				mainModel.log.Printf("Entering rescue mode")
+			}
+			return p, func() tea.Msg { return GoToPageMsg{PageID: "disk_selection"} }
+		}
+	}
+	return p, nil
+}
+
+func (p *welcomePage) View() string {
+	s := ""
+	if p.text != "" {
+		s += p.text + "\n\n"
+	} else {
+		s += "What would you like to do?\n\n"
+	}
+	if p.eula != "" {
+		s += p.eulaView() + "\n\n"
+	}
+	for i, option := range p.options {
+		s += listItem(i, p.cursor, len(p.options), option) + "\n"
+	}
+	return s
+}
+
+// eulaView shows as much of the license as fits above the options, the rest is in the file itself
+func (p *welcomePage) eulaView() string {
+	lines := strings.Split(p.eula, "\n")
+	room := max(contentHeight()-len(p.options)-8, 3)
+	if len(lines) > room {
+		lines = append(lines[:room], fmt.Sprintf("... see %s for the full license", currentBranding().EULA))
+	}
+	s := lipgloss.NewStyle().Faint(true).Render(strings.Join(lines, "\n"))
+	return s + "\n\nBy beginning the installation you accept the license above."
+}
+
+func (p *welcomePage) Title() string {
+	return "Welcome"
+}
+
+func (p *welcomePage) Help() string {
+	return genericNavigationHelp
+}
+
+func (p *welcomePage) ID() string { return "welcome" }
+
+// AutoAdvance begins the installation
+func (p *welcomePage) AutoAdvance() tea.Cmd {
+	mainModel.rescue = false
+	return func() tea.Msg { return GoToPageMsg{PageID: "disk_selection"} }
+}